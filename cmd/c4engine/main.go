@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/engine"
+)
+
+func main() {
+	strategy := flag.String("strategy", connectfour.StrategyMinimax, "strategy to play: "+strings.Join(connectfour.Strategies(), ", "))
+	difficulty := flag.Int("difficulty", 6, "default search depth, the client can change it with setoption")
+	randomize := flag.Bool("randomize", false, "add randomness to the strategy's choices")
+	seed := flag.Int64("seed", 0, "seed for the strategy's randomness, random if 0")
+	flag.Parse()
+
+	// stdout belongs to the protocol
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	config := connectfour.DefaultConfig().SetDifficulty(*difficulty).IncludeRandomization(*randomize)
+	if *seed != 0 {
+		config.SetSeed(*seed)
+	}
+	server, err := engine.NewServer(*strategy, config)
+	if err != nil {
+		log.Fatalf("Failed to create engine: %s", err.Error())
+	}
+	server.Author = "connect-four"
+	if err = server.Serve(os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("Failed to serve: %s", err.Error())
+	}
+}
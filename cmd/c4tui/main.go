@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/matchmaking"
+	"github.com/Zach51920/connect-four/internal/tui"
+	"golang.org/x/term"
+)
+
+func main() {
+	name := flag.String("name", "You", "your player name")
+	strategy := flag.String("strategy", connectfour.StrategyMinimax, "bot strategy to play locally: "+strings.Join(connectfour.Strategies(), ", "))
+	difficulty := flag.Int("difficulty", 6, "bot difficulty")
+	mistakes := flag.Int("mistakes", 5, "how often the bot makes a mistake, out of 100")
+	seed := flag.Int64("seed", 0, "seed for the bot's randomness, random if 0")
+	botFirst := flag.Bool("bot-first", false, "let the bot make the first move")
+	server := flag.String("server", "", "play online through a server's websocket API, e.g. ws://localhost:8080/api/ws")
+	timeControl := flag.String("time-control", matchmaking.TimeControlCasual, "time control to queue for online: "+strings.Join(matchmaking.TimeControls, ", "))
+	flag.Parse()
+
+	// logs would draw over the board
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1})))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var game tui.Game
+	var err error
+	if *server != "" {
+		game, err = tui.DialOnline(ctx, *server, *name, *timeControl)
+	} else {
+		config := connectfour.DefaultConfig().SetDifficulty(*difficulty).SetMistakeFrequency(*mistakes)
+		if *seed != 0 {
+			config.SetSeed(*seed)
+		}
+		game, err = tui.NewLocalGame(*name, *strategy, config, *botFirst)
+	}
+	if err != nil {
+		log.Fatalf("Failed to start game: %s", err.Error())
+	}
+	defer game.Close()
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		log.Fatal("c4tui needs to be run in a terminal")
+	}
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		log.Fatalf("Failed to set up terminal: %s", err.Error())
+	}
+	defer term.Restore(int(os.Stdin.Fd()), oldState)
+
+	fmt.Print("\x1b[?25l")       // hide the cursor
+	defer fmt.Print("\x1b[?25h") // and bring it back
+	if err = tui.Run(ctx, game, os.Stdin, os.Stdout, *server != ""); err != nil {
+		term.Restore(int(os.Stdin.Fd()), oldState)
+		log.Fatalf("Failed to run: %s", err.Error())
+	}
+}
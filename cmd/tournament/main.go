@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Zach51920/connect-four/internal/tournament"
+)
+
+func main() {
+	configPath := flag.String("config", "configs/tournament.yaml", "path to the tournament YAML file")
+	logPath := flag.String("log", "", "write the game log to this file instead of stdout")
+	parallel := flag.Int("parallel", 0, "number of games to run at once, overrides the config file")
+	flag.Parse()
+
+	// keep the engine debug logs out of the report
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	cfg, err := tournament.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load tournament: %s", err.Error())
+	}
+	if *parallel > 0 {
+		cfg.Parallel = *parallel
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	schedule := cfg.Schedule()
+	fmt.Fprintf(os.Stderr, "Running %d games (%s, %d in parallel)\n", len(schedule), cfg.Mode, cfg.Parallel)
+	records := tournament.Run(ctx, cfg, func(record tournament.GameRecord) {
+		fmt.Fprintf(os.Stderr, "game %d/%d: %s vs %s %s\n", record.Number, len(schedule), record.Red.Name, record.Yellow.Name, record.Result)
+	})
+
+	logOut := os.Stdout
+	if *logPath != "" {
+		f, err := os.Create(*logPath)
+		if err != nil {
+			log.Fatalf("Failed to create log file: %s", err.Error())
+		}
+		defer f.Close()
+		logOut = f
+	}
+	if err = tournament.WriteLog(logOut, records); err != nil {
+		log.Fatalf("Failed to write game log: %s", err.Error())
+	}
+	if err = tournament.NewReport(cfg, records).WriteTables(os.Stdout); err != nil {
+		log.Fatalf("Failed to write report: %s", err.Error())
+	}
+}
@@ -0,0 +1,178 @@
+// Package boardimage draws boards as SVG and PNG images, so positions can be shared outside
+// the game. Only the standard library is used, PNG text is drawn with a small bitmap font.
+package boardimage
+
+import (
+	"image/color"
+	"strconv"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+const (
+	DefaultCellSize = 64
+	DefaultTheme    = "classic"
+)
+
+// Theme is the colour scheme of an image.
+type Theme struct {
+	Background color.RGBA
+	Board      color.RGBA
+	Empty      color.RGBA
+	Text       color.RGBA
+	// Highlight rings the winning cells, Marker dots the last move.
+	Highlight color.RGBA
+	Marker    color.RGBA
+	Tokens    map[rune]color.RGBA
+}
+
+var (
+	// ThemeClassic matches the colours of the site.
+	ThemeClassic = Theme{
+		Background: color.RGBA{R: 0x08, G: 0x33, B: 0x44, A: 0xff},
+		Board:      color.RGBA{R: 0x02, G: 0x84, B: 0xc7, A: 0xff},
+		Empty:      color.RGBA{R: 0x03, G: 0x69, B: 0xa1, A: 0xff},
+		Text:       color.RGBA{R: 0xe0, G: 0xf2, B: 0xfe, A: 0xff},
+		Highlight:  color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		Marker:     color.RGBA{R: 0x0c, G: 0x4a, B: 0x6e, A: 0xff},
+		Tokens: map[rune]color.RGBA{
+			'X': {R: 0xef, G: 0x44, B: 0x44, A: 0xff},
+			'O': {R: 0xea, G: 0xb3, B: 0x08, A: 0xff},
+		},
+	}
+	// ThemeLight prints well on white pages.
+	ThemeLight = Theme{
+		Background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		Board:      color.RGBA{R: 0x25, G: 0x63, B: 0xeb, A: 0xff},
+		Empty:      color.RGBA{R: 0xf8, G: 0xfa, B: 0xfc, A: 0xff},
+		Text:       color.RGBA{R: 0x1e, G: 0x29, B: 0x3b, A: 0xff},
+		Highlight:  color.RGBA{R: 0x16, G: 0xa3, B: 0x4a, A: 0xff},
+		Marker:     color.RGBA{R: 0x1e, G: 0x29, B: 0x3b, A: 0xff},
+		Tokens: map[rune]color.RGBA{
+			'X': {R: 0xdc, G: 0x26, B: 0x26, A: 0xff},
+			'O': {R: 0xfa, G: 0xcc, B: 0x15, A: 0xff},
+		},
+	}
+)
+
+// Themes are the themes that can be picked by name.
+var Themes = map[string]Theme{
+	DefaultTheme: ThemeClassic,
+	"light":      ThemeLight,
+}
+
+// ParseTheme looks up a theme by name, unknown names get the classic theme.
+func ParseTheme(name string) Theme {
+	if theme, ok := Themes[name]; ok {
+		return theme
+	}
+	return ThemeClassic
+}
+
+type Options struct {
+	// Theme defaults to ThemeClassic and CellSize to DefaultCellSize.
+	Theme    *Theme
+	CellSize int
+	// Coordinates labels the columns a-g and the rows 1-6 from the bottom, like the move notation.
+	Coordinates bool
+	// Highlight rings the winning cells and LastMove marks the last token dropped.
+	Highlight bool
+	LastMove  bool
+}
+
+func (o Options) theme() Theme {
+	if o.Theme == nil {
+		return ThemeClassic
+	}
+	return *o.Theme
+}
+
+// layout places the board in the image, it's shared by the SVG and PNG renderers.
+type layout struct {
+	board   *connectfour.Board
+	theme   Theme
+	opts    Options
+	cell    int
+	padding int
+	margin  int // room for the coordinates, left of and below the board
+	width   int
+	height  int
+}
+
+func newLayout(board *connectfour.Board, opts Options) layout {
+	cell := opts.CellSize
+	if cell <= 0 {
+		cell = DefaultCellSize
+	}
+	l := layout{board: board, theme: opts.theme(), opts: opts, cell: cell, padding: cell / 8}
+	if opts.Coordinates {
+		l.margin = cell / 2
+	}
+	l.width = board.NumCols()*cell + 2*l.padding + l.margin
+	l.height = board.NumRows()*cell + 2*l.padding + l.margin
+	return l
+}
+
+// boardRect is the board's top left corner and size.
+func (l layout) boardRect() (x, y, w, h int) {
+	return l.margin, 0, l.width - l.margin, l.height - l.margin
+}
+
+// center is the middle of a cell.
+func (l layout) center(row, col int) (x, y float64) {
+	x = float64(l.margin+l.padding+col*l.cell) + float64(l.cell)/2
+	y = float64(l.padding+row*l.cell) + float64(l.cell)/2
+	return x, y
+}
+
+func (l layout) radius() float64 { return float64(l.cell) * 0.4 }
+
+// cell is how a cell is drawn.
+type cell struct {
+	row, col  int
+	fill      color.RGBA
+	highlight bool
+	marker    bool
+}
+
+func (l layout) cells() []cell {
+	lastRow, lastCol, hasLast := l.board.LastMove()
+	cells := make([]cell, 0, l.board.NumRows()*l.board.NumCols())
+	for row := range l.board.NumRows() {
+		for col := range l.board.NumCols() {
+			c := cell{row: row, col: col, fill: l.theme.Empty}
+			if token := l.board.GetCell(row, col); token != 0 {
+				if fill, ok := l.theme.Tokens[token]; ok {
+					c.fill = fill
+				}
+				c.highlight = l.opts.Highlight && l.board.IsWinningCell(row, col)
+				c.marker = l.opts.LastMove && hasLast && row == lastRow && col == lastCol
+			}
+			cells = append(cells, c)
+		}
+	}
+	return cells
+}
+
+// label is a coordinate drawn around the board, x and y are its centre.
+type label struct {
+	text string
+	x, y float64
+}
+
+func (l layout) labels() []label {
+	if !l.opts.Coordinates {
+		return nil
+	}
+	var labels []label
+	rows := l.board.NumRows()
+	for col := range l.board.NumCols() {
+		x, _ := l.center(0, col)
+		labels = append(labels, label{text: connectfour.ColumnName(col), x: x, y: float64(l.height - l.margin/2)})
+	}
+	for row := range rows {
+		_, y := l.center(row, 0)
+		labels = append(labels, label{text: strconv.Itoa(rows - row), x: float64(l.margin) / 2, y: y})
+	}
+	return labels
+}
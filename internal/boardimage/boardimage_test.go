@@ -0,0 +1,125 @@
+package boardimage
+
+import (
+	"bytes"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+// wonBoard plays "aabbccd", X wins along the bottom row.
+func wonBoard(t *testing.T) *connectfour.Board {
+	t.Helper()
+	player1, player2 := connectfour.NewHumanPlayerPair()
+	game := connectfour.NewGame(player1, player2)
+	for _, col := range []int{0, 0, 1, 1, 2, 2, 3} {
+		if _, err := game.Play(game.CurrentPlayer(), col); err != nil {
+			t.Fatalf("failed to play column %d: %v", col, err)
+		}
+		game.NextPlayer()
+	}
+	return game.Board
+}
+
+func TestPNG(t *testing.T) {
+	board := wonBoard(t)
+	opts := Options{CellSize: 32, Coordinates: true, Highlight: true, LastMove: true}
+
+	var buf bytes.Buffer
+	if err := PNG(&buf, board, opts); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	l := newLayout(board, opts)
+	if size := img.Bounds().Size(); size.X != l.width || size.Y != l.height {
+		t.Fatalf("expected a %dx%d image, got %v", l.width, l.height, size)
+	}
+
+	tests := []struct {
+		name     string
+		row, col int
+		dx       float64 // offset from the cell's centre
+		want     string
+	}{
+		{name: "red token", row: 5, col: 1, want: hex(ThemeClassic.Tokens['X'])},
+		{name: "yellow token", row: 4, col: 0, want: hex(ThemeClassic.Tokens['O'])},
+		{name: "empty cell", row: 0, col: 6, want: hex(ThemeClassic.Empty)},
+		{name: "last move marker", row: 5, col: 3, want: hex(ThemeClassic.Marker)},
+		{name: "winning ring", row: 5, col: 0, dx: l.radius() + 1, want: hex(ThemeClassic.Highlight)},
+		{name: "no ring on the loser", row: 4, col: 0, dx: l.radius() + 1, want: hex(ThemeClassic.Board)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := l.center(tt.row, tt.col)
+			r, g, b, _ := img.At(int(x+tt.dx), int(y)).RGBA()
+			got := hex(rgba(r, g, b))
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSVG(t *testing.T) {
+	board := wonBoard(t)
+	var buf bytes.Buffer
+	if err := SVG(&buf, board, Options{Theme: &ThemeLight, Coordinates: true, Highlight: true, LastMove: true}); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	svg := buf.String()
+
+	// 42 cells, 4 winning rings and the last move marker
+	if circles := strings.Count(svg, "<circle"); circles != 42+4+1 {
+		t.Errorf("expected 47 circles, got %d", circles)
+	}
+	for _, want := range []string{">a</text>", ">g</text>", ">1</text>", ">6</text>", hex(ThemeLight.Board)} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("expected the image to contain %q", want)
+		}
+	}
+}
+
+func rgba(r, g, b uint32) color.RGBA {
+	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0xff}
+}
+
+func TestGIF(t *testing.T) {
+	final := wonBoard(t)
+	frames := []*connectfour.Board{connectfour.NewBoard(connectfour.DefaultBoardRows, connectfour.DefaultBoardColumns), final}
+	anim := Animation{Frames: frames, Caption: "Player1 vs Player2: Player1 connected four", Delay: 500 * time.Millisecond, FinalDelay: 3 * time.Second}
+	opts := Options{CellSize: 32, Highlight: true, LastMove: true}
+
+	var buf bytes.Buffer
+	if err := GIF(&buf, anim, opts); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	out, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if len(out.Image) != 2 || out.Delay[0] != 50 || out.Delay[1] != 300 {
+		t.Fatalf("expected 2 frames delayed 50 and 300, got %d frames delayed %v", len(out.Image), out.Delay)
+	}
+
+	// the caption goes in a band under the board, the winners are only ringed on the last frame
+	l := newLayout(final, opts)
+	if height := out.Image[0].Bounds().Dy(); height <= l.height {
+		t.Errorf("expected room for the caption below the %dpx board, got %dpx", l.height, height)
+	}
+	x, y := l.center(5, 0)
+	x += l.radius() + 1
+	for i, want := range []string{hex(ThemeClassic.Board), hex(ThemeClassic.Highlight)} {
+		r, g, b, _ := out.Image[i].At(int(x), int(y)).RGBA()
+		if got := hex(rgba(r, g, b)); got != want {
+			t.Errorf("frame %d: expected %s around the corner cell, got %s", i, want, got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package boardimage
+
+const (
+	glyphWidth  = 3
+	glyphHeight = 5
+)
+
+// font is a 3x5 bitmap font covering the coordinates and captions, anything it doesn't cover
+// is drawn as a question mark.
+var font = map[rune][glyphHeight]string{
+	'0':  {"###", "#.#", "#.#", "#.#", "###"},
+	'1':  {".#.", "##.", ".#.", ".#.", "###"},
+	'2':  {"###", "..#", "###", "#..", "###"},
+	'3':  {"###", "..#", ".##", "..#", "###"},
+	'4':  {"#.#", "#.#", "###", "..#", "..#"},
+	'5':  {"###", "#..", "###", "..#", "###"},
+	'6':  {"###", "#..", "###", "#.#", "###"},
+	'7':  {"###", "..#", ".#.", ".#.", ".#."},
+	'8':  {"###", "#.#", "###", "#.#", "###"},
+	'9':  {"###", "#.#", "###", "..#", "###"},
+	'a':  {"...", ".##", "#.#", "#.#", ".##"},
+	'b':  {"#..", "##.", "#.#", "#.#", "##."},
+	'c':  {"...", ".##", "#..", "#..", ".##"},
+	'd':  {"..#", ".##", "#.#", "#.#", ".##"},
+	'e':  {"...", ".#.", "###", "#..", ".##"},
+	'f':  {"..#", ".#.", "###", ".#.", ".#."},
+	'g':  {"...", ".##", "#.#", ".##", "##."},
+	'h':  {"#..", "##.", "#.#", "#.#", "#.#"},
+	'i':  {".#.", "...", ".#.", ".#.", ".#."},
+	'j':  {"..#", "...", "..#", "#.#", ".#."},
+	'k':  {"#..", "#.#", "##.", "##.", "#.#"},
+	'l':  {"##.", ".#.", ".#.", ".#.", "###"},
+	'm':  {"...", "###", "###", "#.#", "#.#"},
+	'n':  {"...", "##.", "#.#", "#.#", "#.#"},
+	'o':  {"...", ".#.", "#.#", "#.#", ".#."},
+	'p':  {"...", "##.", "#.#", "##.", "#.."},
+	'q':  {"...", ".##", "#.#", ".##", "..#"},
+	'r':  {"...", "#.#", "##.", "#..", "#.."},
+	's':  {".##", "#..", ".#.", "..#", "##."},
+	't':  {".#.", "###", ".#.", ".#.", "..#"},
+	'u':  {"...", "#.#", "#.#", "#.#", ".##"},
+	'v':  {"...", "#.#", "#.#", "#.#", ".#."},
+	'w':  {"...", "#.#", "#.#", "###", "###"},
+	'x':  {"...", "#.#", ".#.", ".#.", "#.#"},
+	'y':  {"...", "#.#", "#.#", ".#.", "#.."},
+	'z':  {"###", "..#", ".#.", "#..", "###"},
+	'A':  {".#.", "#.#", "###", "#.#", "#.#"},
+	'B':  {"##.", "#.#", "##.", "#.#", "##."},
+	'C':  {".##", "#..", "#..", "#..", ".##"},
+	'D':  {"##.", "#.#", "#.#", "#.#", "##."},
+	'E':  {"###", "#..", "##.", "#..", "###"},
+	'F':  {"###", "#..", "##.", "#..", "#.."},
+	'G':  {".##", "#..", "#.#", "#.#", ".##"},
+	'H':  {"#.#", "#.#", "###", "#.#", "#.#"},
+	'I':  {"###", ".#.", ".#.", ".#.", "###"},
+	'J':  {"..#", "..#", "..#", "#.#", ".#."},
+	'K':  {"#.#", "#.#", "##.", "#.#", "#.#"},
+	'L':  {"#..", "#..", "#..", "#..", "###"},
+	'M':  {"#.#", "###", "###", "#.#", "#.#"},
+	'N':  {"##.", "#.#", "#.#", "#.#", "#.#"},
+	'O':  {".#.", "#.#", "#.#", "#.#", ".#."},
+	'P':  {"##.", "#.#", "##.", "#..", "#.."},
+	'Q':  {".#.", "#.#", "#.#", "##.", ".##"},
+	'R':  {"##.", "#.#", "##.", "#.#", "#.#"},
+	'S':  {".##", "#..", ".#.", "..#", "##."},
+	'T':  {"###", ".#.", ".#.", ".#.", ".#."},
+	'U':  {"#.#", "#.#", "#.#", "#.#", "###"},
+	'V':  {"#.#", "#.#", "#.#", "#.#", ".#."},
+	'W':  {"#.#", "#.#", "###", "###", "#.#"},
+	'X':  {"#.#", "#.#", ".#.", "#.#", "#.#"},
+	'Y':  {"#.#", "#.#", ".#.", ".#.", ".#."},
+	'Z':  {"###", "..#", ".#.", "#..", "###"},
+	' ':  {"...", "...", "...", "...", "..."},
+	'.':  {"...", "...", "...", "...", ".#."},
+	',':  {"...", "...", "...", ".#.", "#.."},
+	':':  {"...", ".#.", "...", ".#.", "..."},
+	'-':  {"...", "...", "###", "...", "..."},
+	'_':  {"...", "...", "...", "...", "###"},
+	'!':  {".#.", ".#.", ".#.", "...", ".#."},
+	'?':  {"##.", "..#", ".#.", "...", ".#."},
+	'\'': {".#.", ".#.", "...", "...", "..."},
+	'(':  {".#.", "#..", "#..", "#..", ".#."},
+	')':  {".#.", "..#", "..#", "..#", ".#."},
+}
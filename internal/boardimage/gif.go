@@ -0,0 +1,115 @@
+package boardimage
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+// Animation is a game played out one board per frame.
+type Animation struct {
+	Frames []*connectfour.Board
+	// Caption is written under every frame, like the players and the result.
+	Caption string
+	// Delay is how long each frame is shown, FinalDelay is how long the last frame is held
+	// before the animation loops.
+	Delay      time.Duration
+	FinalDelay time.Duration
+}
+
+// GIF writes the animation as a looping GIF.
+func GIF(w io.Writer, anim Animation, opts Options) error {
+	if len(anim.Frames) == 0 {
+		return nil
+	}
+
+	frames := make([]*image.RGBA, len(anim.Frames))
+	for i, board := range anim.Frames {
+		frames[i] = drawFrame(board, anim.Caption, opts)
+	}
+	// the last frame has every colour the others do, plus the highlighted winners
+	p := newPaletter(frames[len(frames)-1])
+
+	out := &gif.GIF{LoopCount: 0}
+	for i, frame := range frames {
+		delay := anim.Delay
+		if i == len(frames)-1 {
+			delay = anim.FinalDelay
+		}
+		out.Image = append(out.Image, p.convert(frame))
+		out.Delay = append(out.Delay, int(delay/(10*time.Millisecond)))
+	}
+	return gif.EncodeAll(w, out)
+}
+
+// drawFrame draws the board with the caption in a band underneath.
+func drawFrame(board *connectfour.Board, caption string, opts Options) *image.RGBA {
+	l := newLayout(board, opts)
+	boardImg := Draw(board, opts)
+	if caption == "" {
+		return boardImg
+	}
+
+	band := l.cell / 2
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height+band))
+	draw.Draw(img, boardImg.Bounds(), boardImg, image.Point{}, draw.Src)
+	fillRect(img, image.Rect(0, l.height, l.width, l.height+band), l.theme.Background)
+
+	scale := max(1, l.cell/32)
+	text := fitText(caption, l.width-2*l.padding, scale)
+	drawText(img, text, float64(l.width)/2, float64(l.height+band/2), scale, l.theme.Text)
+	return img
+}
+
+// paletter maps frames onto a shared palette of the most common colours in a frame. Board
+// images have a handful of flat colours plus the anti-aliased edges between them, so the
+// colours that don't make the cut are close to ones that do.
+type paletter struct {
+	palette color.Palette
+	index   map[color.RGBA]uint8
+}
+
+func newPaletter(img *image.RGBA) *paletter {
+	counts := make(map[color.RGBA]int)
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			counts[img.RGBAAt(x, y)]++
+		}
+	}
+	colors := make([]color.RGBA, 0, len(counts))
+	for c := range counts {
+		colors = append(colors, c)
+	}
+	sort.Slice(colors, func(i, j int) bool { return counts[colors[i]] > counts[colors[j]] })
+
+	p := &paletter{index: make(map[color.RGBA]uint8)}
+	for i, c := range colors[:min(len(colors), 256)] {
+		p.palette = append(p.palette, c)
+		p.index[c] = uint8(i)
+	}
+	return p
+}
+
+func (p *paletter) convert(img *image.RGBA) *image.Paletted {
+	b := img.Bounds()
+	out := image.NewPaletted(b, p.palette)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			i, ok := p.index[c]
+			if !ok {
+				i = uint8(p.palette.Index(c))
+				p.index[c] = i
+			}
+			out.SetColorIndex(x, y, i)
+		}
+	}
+	return out
+}
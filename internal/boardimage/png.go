@@ -0,0 +1,136 @@
+package boardimage
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"unicode/utf8"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+// PNG writes the board as a PNG image.
+func PNG(w io.Writer, board *connectfour.Board, opts Options) error {
+	return png.Encode(w, Draw(board, opts))
+}
+
+// Draw draws the board onto a new image.
+func Draw(board *connectfour.Board, opts Options) *image.RGBA {
+	l := newLayout(board, opts)
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	fillRect(img, img.Bounds(), l.theme.Background)
+	x, y, width, height := l.boardRect()
+	fillRoundedRect(img, image.Rect(x, y, x+width, y+height), float64(l.padding), l.theme.Board)
+
+	r := l.radius()
+	for _, c := range l.cells() {
+		cx, cy := l.center(c.row, c.col)
+		if c.highlight {
+			fillCircle(img, cx, cy, r+float64(l.cell)/16, l.theme.Highlight)
+		}
+		fillCircle(img, cx, cy, r, c.fill)
+		if c.marker {
+			fillCircle(img, cx, cy, r/4, l.theme.Marker)
+		}
+	}
+
+	scale := max(1, l.cell/32)
+	for _, lbl := range l.labels() {
+		drawText(img, lbl.text, lbl.x, lbl.y, scale, l.theme.Text)
+	}
+	return img
+}
+
+func fillRect(img *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	rect = rect.Intersect(img.Bounds())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// fillRoundedRect fills the rectangle with its corners rounded off to radius r.
+func fillRoundedRect(img *image.RGBA, rect image.Rectangle, r float64, c color.RGBA) {
+	rect = rect.Intersect(img.Bounds())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			// distance past the nearest corner's centre, zero away from the corners
+			px, py := float64(x)+0.5, float64(y)+0.5
+			dx := max(float64(rect.Min.X)+r-px, px-(float64(rect.Max.X)-r), 0)
+			dy := max(float64(rect.Min.Y)+r-py, py-(float64(rect.Max.Y)-r), 0)
+			blend(img, x, y, c, coverage(r-math.Hypot(dx, dy)))
+		}
+	}
+}
+
+// fillCircle fills a circle with anti-aliased edges.
+func fillCircle(img *image.RGBA, cx, cy, r float64, c color.RGBA) {
+	rect := image.Rect(int(cx-r)-1, int(cy-r)-1, int(cx+r)+2, int(cy+r)+2).Intersect(img.Bounds())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			d := math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+			blend(img, x, y, c, coverage(r-d))
+		}
+	}
+}
+
+// coverage is how much of a pixel is inside a shape, given how far its centre is inside the edge.
+func coverage(inside float64) float64 {
+	return min(max(inside+0.5, 0), 1)
+}
+
+// blend draws c over the pixel at x, y with the given opacity.
+func blend(img *image.RGBA, x, y int, c color.RGBA, alpha float64) {
+	if alpha <= 0 {
+		return
+	}
+	if alpha >= 1 {
+		img.SetRGBA(x, y, c)
+		return
+	}
+	bg := img.RGBAAt(x, y)
+	mix := func(a, b uint8) uint8 { return uint8(float64(a)*alpha + float64(b)*(1-alpha) + 0.5) }
+	img.SetRGBA(x, y, color.RGBA{R: mix(c.R, bg.R), G: mix(c.G, bg.G), B: mix(c.B, bg.B), A: mix(c.A, bg.A)})
+}
+
+// drawText draws the text centred on x, y with the bitmap font, each font pixel is scale pixels.
+func drawText(img *image.RGBA, text string, x, y float64, scale int, c color.RGBA) {
+	left := int(x) - textWidth(text, scale)/2
+	top := int(y) - glyphHeight*scale/2
+	i := 0
+	for _, r := range text {
+		glyph, ok := font[r]
+		if !ok {
+			glyph = font['?']
+		}
+		for gy, line := range glyph {
+			for gx, px := range line {
+				if px != '#' {
+					continue
+				}
+				x0, y0 := left+i*(glyphWidth+1)*scale+gx*scale, top+gy*scale
+				fillRect(img, image.Rect(x0, y0, x0+scale, y0+scale), c)
+			}
+		}
+		i++
+	}
+}
+
+func textWidth(text string, scale int) int {
+	return max(utf8.RuneCountInString(text)*(glyphWidth+1)*scale-scale, 0)
+}
+
+// fitText shortens the text to fit within width pixels.
+func fitText(text string, width, scale int) string {
+	if textWidth(text, scale) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && textWidth(string(runes)+"..", scale) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + ".."
+}
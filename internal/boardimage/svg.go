@@ -0,0 +1,44 @@
+package boardimage
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+// SVG writes the board as an SVG image.
+func SVG(w io.Writer, board *connectfour.Board, opts Options) error {
+	l := newLayout(board, opts)
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, l.width, l.height, l.width, l.height)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="%s"/>`, l.width, l.height, hex(l.theme.Background))
+	x, y, width, height := l.boardRect()
+	fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" rx="%d" fill="%s"/>`, x, y, width, height, l.padding, hex(l.theme.Board))
+
+	r := l.radius()
+	for _, c := range l.cells() {
+		cx, cy := l.center(c.row, c.col)
+		if c.highlight {
+			fmt.Fprintf(bw, `<circle cx="%g" cy="%g" r="%g" fill="%s"/>`, cx, cy, r+float64(l.cell)/16, hex(l.theme.Highlight))
+		}
+		fmt.Fprintf(bw, `<circle cx="%g" cy="%g" r="%g" fill="%s"/>`, cx, cy, r, hex(c.fill))
+		if c.marker {
+			fmt.Fprintf(bw, `<circle cx="%g" cy="%g" r="%g" fill="%s"/>`, cx, cy, r/4, hex(l.theme.Marker))
+		}
+	}
+
+	for _, lbl := range l.labels() {
+		fmt.Fprintf(bw, `<text x="%g" y="%g" fill="%s" font-family="sans-serif" font-size="%d" text-anchor="middle" dominant-baseline="central">%s</text>`,
+			lbl.x, lbl.y, hex(l.theme.Text), l.cell/4, lbl.text)
+	}
+	fmt.Fprint(bw, `</svg>`)
+	return bw.Flush()
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -1,12 +1,19 @@
 package config
 
 import (
+	"fmt"
+	"github.com/Zach51920/connect-four/internal/ratelimit"
+	"github.com/Zach51920/connect-four/internal/tracing"
 	"github.com/gin-gonic/gin"
 	"gopkg.in/yaml.v3"
 	"log"
 	"log/slog"
+	"net/url"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -14,23 +21,96 @@ type Config struct {
 	Server   *ServerConfig `yaml:"server"`
 }
 
+const (
+	StorageMock    = "mock"
+	StorageMongoDB = "mongodb"
+	StorageSQLite  = "sqlite"
+)
+
 type ServerConfig struct {
-	Address     string `yaml:"address"`
-	GinMode     string `yaml:"gin_mode"`
-	WithMongoDB bool   `yaml:"with_mongodb"`
+	Address     string             `yaml:"address"`
+	GinMode     string             `yaml:"gin_mode"`
+	Storage     string             `yaml:"storage"`
+	WebhookBots []WebhookBotConfig `yaml:"webhook_bots"`
+	Tracing     *tracing.Config    `yaml:"tracing"`
+
+	// ReadTimeout, WriteTimeout and IdleTimeout are passed to the http.Server. Game streams stay
+	// open for the whole game so a write timeout cuts them off, it's disabled by default.
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout is how long in-flight requests get to finish after a shutdown signal.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// BotMoveDelay is the pause before each bot move, so games against bots can be followed.
+	BotMoveDelay *time.Duration `yaml:"bot_move_delay"`
+	// ReplayCacheDir is where replay GIFs of finished games are kept, it's a temporary
+	// directory by default.
+	ReplayCacheDir string `yaml:"replay_cache_dir"`
+	// PublicURL is the scheme and host players reach the site on, like "https://example.com".
+	// Shared pages link to themselves with it, without one their links are relative.
+	PublicURL string `yaml:"public_url"`
+
+	RateLimit *RateLimitConfig `yaml:"rate_limit"`
+	// TrustedProxies are the proxies whose X-Forwarded-For header is believed, the client IP
+	// the rate limits are kept by comes from it. None are trusted by default.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+
+	// WithMongoDB was replaced by Storage, it's only read so old configs fail loudly rather
+	// than quietly running on the mock repository.
+	WithMongoDB *bool `yaml:"with_mongodb"`
+}
+
+// RateLimitConfig limits the requests that create games, make moves and configure bots.
+// Anything left unset gets a default.
+type RateLimitConfig struct {
+	// Session and IP are token buckets kept per session ID and per client IP.
+	Session ratelimit.Config `yaml:"session"`
+	IP      ratelimit.Config `yaml:"ip"`
+	// BotSearches is how many bot moves the game runner searches for at once, BotQueue is how
+	// many games can wait for a free worker before requests are turned away.
+	BotSearches int `yaml:"bot_searches"`
+	BotQueue    int `yaml:"bot_queue"`
+}
+
+// WebhookBotConfig describes a bot running as an HTTP service. Environment variables in the
+// secret are expanded so it can stay out of the config file.
+type WebhookBotConfig struct {
+	Name    string        `yaml:"name"`
+	URL     string        `yaml:"url"`
+	Secret  string        `yaml:"secret"`
+	Timeout time.Duration `yaml:"timeout"`
+	Retries *int          `yaml:"retries"`
 }
 
+// Load reads the config file and exits if it can't be used.
 func Load(path string) *Config {
+	config, err := Parse(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return config
+}
+
+// Parse reads the config file.
+func Parse(path string) (*Config, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatalf("failed to read config file: %v", err)
+		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
 	config := new(Config)
 	if err = yaml.Unmarshal(b, config); err != nil {
-		log.Fatalf("failed to parse config file: %v", err)
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
-	return config
+	if config.Server != nil {
+		if err = config.Server.validateStorage(); err != nil {
+			return nil, fmt.Errorf("invalid config file: %w", err)
+		}
+		if err = config.Server.validatePublicURL(); err != nil {
+			return nil, fmt.Errorf("invalid config file: %w", err)
+		}
+	}
+	return config, nil
 }
 
 func (c *Config) ParseLogLevel() slog.Level {
@@ -60,3 +140,88 @@ func (c *ServerConfig) ParseGinMode() string {
 		return gin.ReleaseMode
 	}
 }
+
+func (c *ServerConfig) ParseRateLimit() RateLimitConfig {
+	var cfg RateLimitConfig
+	if c.RateLimit != nil {
+		cfg = *c.RateLimit
+	}
+	if cfg.Session.Rate <= 0 {
+		cfg.Session = ratelimit.Config{Rate: 5, Burst: 10}
+	}
+	if cfg.IP.Rate <= 0 {
+		cfg.IP = ratelimit.Config{Rate: 20, Burst: 40}
+	}
+	cfg.Session.Burst = max(cfg.Session.Burst, 1)
+	cfg.IP.Burst = max(cfg.IP.Burst, 1)
+	if cfg.BotSearches <= 0 {
+		cfg.BotSearches = runtime.NumCPU()
+	}
+	if cfg.BotQueue <= 0 {
+		cfg.BotQueue = 4 * cfg.BotSearches
+	}
+	return cfg
+}
+
+func (c *ServerConfig) ParseBotMoveDelay() time.Duration {
+	if c.BotMoveDelay == nil {
+		return 300 * time.Millisecond
+	}
+	return max(*c.BotMoveDelay, 0)
+}
+
+func (c *ServerConfig) ParseReplayCacheDir() string {
+	if c.ReplayCacheDir == "" {
+		return filepath.Join(os.TempDir(), "connect-four-replays")
+	}
+	return c.ReplayCacheDir
+}
+
+// ParsePublicURL is the public URL without a trailing slash, so paths can be appended to it.
+func (c *ServerConfig) ParsePublicURL() string {
+	return strings.TrimRight(c.PublicURL, "/")
+}
+
+// validatePublicURL makes sure the public URL is an absolute http(s) URL, links shared from
+// the site would be broken otherwise.
+func (c *ServerConfig) validatePublicURL() error {
+	if c.PublicURL == "" {
+		return nil
+	}
+	u, err := url.Parse(c.PublicURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("public_url %q must be an absolute http or https URL", c.PublicURL)
+	}
+	return nil
+}
+
+// ParseStorage is the repository backend, the mock repository is used when none is set.
+func (c *ServerConfig) ParseStorage() string {
+	storage, _ := parseStorage(c.Storage)
+	return storage
+}
+
+// validateStorage rejects storage values that aren't known, and the with_mongodb flag it
+// replaced, so a typo doesn't quietly lose every saved game.
+func (c *ServerConfig) validateStorage() error {
+	if c.WithMongoDB != nil {
+		return fmt.Errorf("with_mongodb has been replaced, set storage to %s, %s or %s", StorageMongoDB, StorageSQLite, StorageMock)
+	}
+	if _, ok := parseStorage(c.Storage); !ok {
+		return fmt.Errorf("unknown storage %q, expected %s, %s or %s", c.Storage, StorageMongoDB, StorageSQLite, StorageMock)
+	}
+	return nil
+}
+
+func parseStorage(storage string) (string, bool) {
+	switch strings.ToLower(storage) {
+	case "mongo", "mongodb":
+		return StorageMongoDB, true
+	case "sqlite", "sqlite3":
+		return StorageSQLite, true
+	case "", "mock":
+		return StorageMock, true
+	default:
+		return StorageMock, false
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestConfigs loads every server config in configs, a config that doesn't parse keeps the
+// server from starting. Tournament configs are tested in the tournament package.
+func TestConfigs(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "..", "configs", "*.yaml"))
+	if err != nil {
+		t.Fatalf("failed to list configs: %v", err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("expected to find configs")
+	}
+
+	for _, path := range paths {
+		if strings.HasPrefix(filepath.Base(path), "tournament") {
+			continue
+		}
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			config, err := Parse(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			if config.Server == nil {
+				t.Fatal("expected a server section")
+			}
+		})
+	}
+}
+
+func TestParse_Storage(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  string
+		want    string
+		wantErr bool
+	}{
+		{"unset", "address: :8080", StorageMock, false},
+		{"mock", "storage: mock", StorageMock, false},
+		{"mongo", "storage: mongo", StorageMongoDB, false},
+		{"sqlite", "storage: SQLite3", StorageSQLite, false},
+		{"unknown", "storage: postgres", "", true},
+		{"with_mongodb", "with_mongodb: true", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte("server:\n  "+tt.server+"\n"), 0o644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			config, err := Parse(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %t, got %v", tt.wantErr, err)
+			}
+			if err == nil && config.Server.ParseStorage() != tt.want {
+				t.Errorf("expected storage %q, got %q", tt.want, config.Server.ParseStorage())
+			}
+		})
+	}
+}
+
+func TestParse_PublicURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		publicURL string
+		want      string
+		wantErr   bool
+	}{
+		{"unset", `""`, "", false},
+		{"https", "https://example.com", "https://example.com", false},
+		{"trailing slash", "http://localhost:8080/", "http://localhost:8080", false},
+		{"no scheme", "example.com", "", true},
+		{"other scheme", "javascript://example.com", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte("server:\n  public_url: "+tt.publicURL+"\n"), 0o644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			config, err := Parse(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %t, got %v", tt.wantErr, err)
+			}
+			if err == nil && config.Server.ParsePublicURL() != tt.want {
+				t.Errorf("expected public URL %q, got %q", tt.want, config.Server.ParsePublicURL())
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package connectfour
+
+// Analysis is the engine's view of a game's position.
+type Analysis struct {
+	// Score is from the first player's point of view, a won position scores WinScore.
+	Score float64
+	// PV is the line of play the engine expects, starting with the player to move.
+	PV []int
+}
+
+// Analyze searches the game's position to the given depth. The search plays it straight,
+// without the randomness and mistakes bots play with, and isn't counted in the bot metrics.
+// Finished games are scored by their result.
+func (g *Game) Analyze(depth int) Analysis {
+	first := g.Players[0]
+	switch {
+	case g.State == GameStateWin && g.Winner == first:
+		return Analysis{Score: WinScore}
+	case g.State == GameStateWin:
+		return Analysis{Score: -WinScore}
+	case g.State == GameStateDraw || g.Board.IsFull():
+		return Analysis{}
+	}
+
+	strat := &MinimaxStrat{Config: &Config{Difficulty: depth}, analysis: true}
+	token := g.CurrentPlayer().Token()
+	suggestion := strat.Suggest(g.Board, token)
+	score := suggestion.Score
+	if token != first.Token() {
+		score = -score
+	}
+	return Analysis{Score: score, PV: suggestion.PV}
+}
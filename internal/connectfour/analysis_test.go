@@ -0,0 +1,66 @@
+package connectfour
+
+import (
+	"testing"
+
+	"github.com/Zach51920/connect-four/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestGame_Analyze(t *testing.T) {
+	play := func(cols ...int) *Game {
+		player1, player2 := NewHumanPlayerPair()
+		game := NewGame(player1, player2)
+		for _, col := range cols {
+			if _, err := game.Play(game.CurrentPlayer(), col); err != nil {
+				t.Fatalf("failed to play column %d: %v", col, err)
+			}
+			game.NextPlayer()
+		}
+		return game
+	}
+
+	t.Run("first player to win", func(t *testing.T) {
+		analysis := play(0, 6, 1, 6, 2, 5).Analyze(4)
+		if analysis.Score != WinScore {
+			t.Errorf("expected a score of %v, got %v", WinScore, analysis.Score)
+		}
+		if len(analysis.PV) == 0 || analysis.PV[0] != 3 {
+			t.Errorf("expected the line to start with column 3, got %v", analysis.PV)
+		}
+	})
+
+	t.Run("second player to win", func(t *testing.T) {
+		analysis := play(6, 0, 6, 1, 5, 2, 4).Analyze(4)
+		if analysis.Score != -WinScore {
+			t.Errorf("expected a score of %v, got %v", -WinScore, analysis.Score)
+		}
+		if len(analysis.PV) == 0 || analysis.PV[0] != 3 {
+			t.Errorf("expected the line to start with column 3, got %v", analysis.PV)
+		}
+	})
+
+	t.Run("finished game", func(t *testing.T) {
+		game := play(0, 6, 1, 6, 2, 5, 3)
+		if analysis := game.Analyze(4); analysis.Score != WinScore || len(analysis.PV) != 0 {
+			t.Errorf("expected a won game to score %v without a line, got %v %v", WinScore, analysis.Score, analysis.PV)
+		}
+	})
+
+	t.Run("bot metrics are left alone", func(t *testing.T) {
+		searches := func() uint64 {
+			var m dto.Metric
+			observer := metrics.NodesSearched.WithLabelValues("4")
+			if err := observer.(prometheus.Histogram).Write(&m); err != nil {
+				t.Fatalf("failed to read the metric: %v", err)
+			}
+			return m.GetHistogram().GetSampleCount()
+		}
+		before := searches()
+		play(3, 3).Analyze(4)
+		if after := searches(); after != before {
+			t.Errorf("expected the analysis not to be counted as a bot search, got %d more", after-before)
+		}
+	})
+}
@@ -9,6 +9,9 @@ const (
 
 	winWeight    = 1000
 	centerWeight = 5
+
+	// WinScore is the score of a won position, anything less is the evaluation's best guess.
+	WinScore = winWeight
 )
 
 var ErrInvalidMove = errors.New("invalid move")
@@ -109,6 +112,13 @@ func (b *Board) CheckWin(token rune) bool {
 	return false
 }
 
+// LastMove returns the cell of the last token dropped, ok is false on an empty board. Until a
+// token is dropped the last move points at the top left cell, which is only filled by a drop.
+func (b *Board) LastMove() (row, col int, ok bool) {
+	row, col = b.lastMove[0], b.lastMove[1]
+	return row, col, b.Cells[row][col] != 0
+}
+
 func (b *Board) NumRows() int {
 	return len(b.Cells)
 }
@@ -1,15 +1,27 @@
 package connectfour
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"math/rand"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/metrics"
+	"github.com/Zach51920/connect-four/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Config struct {
 	MistakeFrequency int
 	Difficulty       int
 	Randomize        bool
+	Seed             int64
+	rand             *rand.Rand
 }
 
 func DefaultConfig() *Config {
@@ -17,9 +29,47 @@ func DefaultConfig() *Config {
 		MistakeFrequency: 5,
 		Difficulty:       6,
 		Randomize:        true,
+		Seed:             rand.Int63(),
+	}
+}
+
+// Copy returns a copy of the config with a fresh random source seeded from Seed.
+func (c *Config) Copy() *Config {
+	return &Config{
+		MistakeFrequency: c.MistakeFrequency,
+		Difficulty:       c.Difficulty,
+		Randomize:        c.Randomize,
+		Seed:             c.Seed,
+	}
+}
+
+// Rand returns the bots random source, all of a bots randomness must come from here so
+// games played with the same seeds are reproducible.
+func (c *Config) Rand() *rand.Rand {
+	if c.rand == nil {
+		c.rand = rand.New(rand.NewSource(c.Seed))
+	}
+	return c.rand
+}
+
+// sameSettings reports whether both configs have the same settings, their random sources may
+// differ.
+func (c *Config) sameSettings(other *Config) bool {
+	return c.MistakeFrequency == other.MistakeFrequency && c.Difficulty == other.Difficulty &&
+		c.Randomize == other.Randomize && c.Seed == other.Seed
+}
+
+// apply changes the config to the other's settings, the random source is only reset if the seed
+// changes.
+func (c *Config) apply(other *Config) {
+	c.SetDifficulty(other.Difficulty).SetMistakeFrequency(other.MistakeFrequency).IncludeRandomization(other.Randomize)
+	if other.Seed != c.Seed {
+		c.SetSeed(other.Seed)
 	}
 }
 
+func (c *Config) SetSeed(seed int64) *Config { c.Seed = seed; c.rand = nil; return c }
+
 func (c *Config) SetMistakeFrequency(freq int) *Config { c.MistakeFrequency = freq; return c }
 
 func (c *Config) SetDifficulty(difficulty int) *Config { c.Difficulty = difficulty; return c }
@@ -28,7 +78,77 @@ func (c *Config) IncludeRandomization(randomize bool) *Config { c.Randomize = ra
 
 type Strategy interface {
 	Name() string
-	Suggest(board *Board, token rune) int
+	Suggest(board *Board, token rune) Suggestion
+}
+
+// Suggestion is a strategy's move for a position. Strategies that don't score positions leave
+// Score and PV empty.
+type Suggestion struct {
+	Column int
+	// Score rates the position for the player to move, a forced win scores WinScore.
+	Score float64
+	// PV is the principal variation, the line of play the strategy expects starting with Column.
+	PV []int
+}
+
+// Configurable is implemented by strategies that can search with another config, bots search
+// with a copy of theirs so it can be changed mid-search. Strategies that aren't configurable
+// read the bot's config as it changes.
+type Configurable interface {
+	WithConfig(config *Config) Strategy
+}
+
+// HistoryStrategy is implemented by strategies that are told the moves played to reach the
+// position. The moves come from the game, they aren't tracked through the search.
+type HistoryStrategy interface {
+	SuggestAfter(moves []Move, board *Board, token rune) Suggestion
+}
+
+// SearchStats is implemented by strategies that can report on their last search.
+type SearchStats interface {
+	LastSearch() (depth, nodes int)
+}
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = make(map[string]func(config *Config) (Strategy, error))
+)
+
+// RegisterStrategy makes a strategy available to NewStrategy under its name. Registering a name
+// twice replaces the first, the built-in strategies can't be replaced.
+func RegisterStrategy(name string, factory func(config *Config) (Strategy, error)) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	strategies[name] = factory
+}
+
+// Strategies lists the names NewStrategy accepts, built-in strategies first.
+func Strategies() []string {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	registered := make([]string, 0, len(strategies))
+	for name := range strategies {
+		if name != StrategyMinimax {
+			registered = append(registered, name)
+		}
+	}
+	slices.Sort(registered)
+	return append([]string{StrategyMinimax}, registered...)
+}
+
+// NewStrategy creates a built-in or registered strategy by its name.
+func NewStrategy(name string, config *Config) (Strategy, error) {
+	if name == StrategyMinimax {
+		return NewMinimaxStrat(config), nil
+	}
+
+	strategiesMu.RLock()
+	factory, ok := strategies[name]
+	strategiesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy: %s", name)
+	}
+	return factory(config)
 }
 
 type BotPlayer struct {
@@ -37,19 +157,70 @@ type BotPlayer struct {
 	strategy Strategy
 }
 
+func NewBotPlayer(name string, token rune, config *Config, strategy Strategy) *BotPlayer {
+	return &BotPlayer{
+		Config:     config,
+		strategy:   strategy,
+		BasePlayer: NewBasePlayer(name, token),
+	}
+}
+
+// Evaluate picks the bot's move for the position. Strategies that want the game's moves get
+// none, EvaluateGame passes them on.
 func (p *BotPlayer) Evaluate(board *Board) int {
-	if col := p.initialEval(board); col != -1 {
+	return p.evaluate(context.Background(), board, nil)
+}
+
+// EvaluateGame picks the bot's move in the game, traced as part of ctx.
+func (p *BotPlayer) EvaluateGame(ctx context.Context, game *Game) int {
+	return p.evaluate(ctx, game.Board, game.Moves)
+}
+
+func (p *BotPlayer) evaluate(ctx context.Context, board *Board, moves []Move) (col int) {
+	_, span := tracing.Tracer().Start(ctx, "BotPlayer.Evaluate", trace.WithAttributes(
+		attribute.String("bot.id", p.ID()),
+		attribute.String("bot.strategy", p.strategy.Name()),
+		attribute.Int("bot.difficulty", p.Config.Difficulty),
+	))
+	defer func(start time.Time) {
+		metrics.BotThinkTime.WithLabelValues(p.strategy.Name(), strconv.Itoa(p.Config.Difficulty)).Observe(time.Since(start).Seconds())
+		span.SetAttributes(attribute.Int("bot.column", col))
+		span.End()
+	}(time.Now())
+
+	if col = p.initialEval(board); col != -1 {
+		span.SetAttributes(attribute.Bool("bot.searched", false))
 		return col
 	}
-	return p.strategy.Suggest(board, p.token)
+	if history, ok := p.strategy.(HistoryStrategy); ok {
+		col = history.SuggestAfter(moves, board, p.token).Column
+	} else {
+		col = p.strategy.Suggest(board, p.token).Column
+	}
+	span.SetAttributes(attribute.Bool("bot.searched", true))
+	if stats, ok := p.strategy.(SearchStats); ok {
+		depth, nodes := stats.LastSearch()
+		span.SetAttributes(attribute.Int("bot.depth", depth), attribute.Int("bot.nodes", nodes))
+	}
+	return col
+}
+
+// AcceptsDraw decides whether the bot takes a draw offer, it only plays on when it has a winning
+// move or rates its own position ahead.
+func (p *BotPlayer) AcceptsDraw(board *Board) bool {
+	if _, isWin := isWinningTurn(board, p.token); isWin {
+		return false
+	}
+	return board.Evaluate(p.token, tokenSwitch[p.token]) <= 0
 }
 
 func (p *BotPlayer) initialEval(board *Board) int {
-	if rand.Intn(100-p.Config.MistakeFrequency+1) == 0 {
+	rng := p.Config.Rand()
+	if rng.Intn(100-p.Config.MistakeFrequency+1) == 0 {
 		slog.Debug("bot is making an intentional mistake")
 		// make a mistake, return random column
 		validCols := board.validColumns()
-		return validCols[rand.Intn(len(validCols))]
+		return validCols[rng.Intn(len(validCols))]
 	}
 
 	// check for immediate win or block
@@ -65,12 +236,25 @@ func (p *BotPlayer) initialEval(board *Board) int {
 
 func (p *BotPlayer) Strategy() string { return p.strategy.Name() }
 
-func randomUsername() string {
+// searcher copies the bot to search with. The copy's config can't be changed from outside, but
+// it shares the random source so the bot's moves still follow from its seed.
+func (p *BotPlayer) searcher() *BotPlayer {
+	bot := *p
+	config := *p.Config
+	config.rand = p.Config.Rand()
+	bot.Config = &config
+	if strategy, ok := p.strategy.(Configurable); ok {
+		bot.strategy = strategy.WithConfig(&config)
+	}
+	return &bot
+}
+
+func randomUsername(rng *rand.Rand) string {
 	adjectives := []string{"Squeaky", "Fluffy", "Snazzy", "Clumsy", "Derpy", "Zesty", "Wacky"}
 	nouns := []string{"Whale", "Pigeon", "Donut", "Panda", "Noodle", "Giraffe", "Raccoon"}
 
-	adj := adjectives[rand.Intn(len(adjectives))]
-	noun := nouns[rand.Intn(len(nouns))]
+	adj := adjectives[rng.Intn(len(adjectives))]
+	noun := nouns[rng.Intn(len(nouns))]
 	return fmt.Sprintf("%s %s", adj, noun)
 }
 
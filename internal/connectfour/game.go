@@ -1,8 +1,11 @@
 package connectfour
 
 import (
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"math"
+	"time"
 )
 
 type GameState int
@@ -22,25 +25,82 @@ const (
 	minBaseScore = 3.0
 )
 
+// Result is how a finished game was decided.
+type Result string
+
+const (
+	ResultConnectFour Result = "connect_four"
+	ResultBoardFull   Result = "board_full"
+	ResultResignation Result = "resignation"
+	ResultAgreement   Result = "agreement"
+)
+
+const VariantStandard = "standard"
+
+var (
+	ErrNotPlayersTurn = errors.New("not players turn")
+	ErrGameOver       = errors.New("game is over")
+	ErrNotInGame      = errors.New("player is not in the game")
+	ErrNoDrawOffer    = errors.New("no draw offer to answer")
+)
+
 var tokenSwitch = map[rune]rune{'X': 'O', 'O': 'X'}
 
+// Move is a single ply as it was played, with enough detail to replay the game faithfully.
+type Move struct {
+	Column     int
+	PlayerID   string
+	ThinkTime  time.Duration
+	ScoreDelta uint64
+	// Config is set on a bot's move when its config changed since its last move, replays apply
+	// it before playing the move.
+	Config *Config
+}
+
+// RatingChange is how a players rating moved as a result of the game.
+type RatingChange struct {
+	Before float64
+	After  float64
+}
+
+func (c RatingChange) Delta() float64 { return c.After - c.Before }
+
 type Game struct {
 	ID               string
+	Variant          string
 	Players          [2]Player
 	Board            *Board
 	State            GameState
 	Winner           Player
+	Result           Result
+	ResignedBy       Player
+	DrawOfferedBy    Player // the player waiting on an answer to their draw offer
+	RematchBy        Player // the player waiting on an answer to their rematch request
+	Series           *Series
 	MoveCount        int
+	Moves            []Move
+	RatingChanges    map[string]RatingChange // keyed by player ID, set once the game is rated
 	currentPlayerIdx int
+	turnStartedAt    time.Time
+	// each bot's config when the game was created, and when it last moved
+	setupConfigs  [2]*Config
+	playedConfigs [2]*Config
 }
 
 func NewGame(player1, player2 Player) *Game {
-	return &Game{
-		ID:      uuid.New().String(),
-		State:   GameStateNew,
-		Players: [2]Player{player1, player2},
-		Board:   NewBoard(DefaultBoardRows, DefaultBoardColumns),
+	players := [2]Player{player1, player2}
+	series, _ := NewSeries(DefaultSeriesBestOf, players)
+	game := &Game{
+		ID:            uuid.New().String(),
+		Variant:       VariantStandard,
+		State:         GameStateNew,
+		Players:       players,
+		Board:         NewBoard(DefaultBoardRows, DefaultBoardColumns),
+		Series:        series,
+		turnStartedAt: time.Now(),
 	}
+	game.recordSetup()
+	return game
 }
 
 func (g *Game) Restart() {
@@ -49,25 +109,35 @@ func (g *Game) Restart() {
 	g.Board = NewBoard(g.Board.NumRows(), g.Board.NumCols())
 	g.currentPlayerIdx = 0
 	g.MoveCount = 0
+	g.Moves = nil
+	g.RatingChanges = nil
 	g.Winner = nil
+	g.Result = ""
+	g.ResignedBy = nil
+	g.DrawOfferedBy = nil
+	g.RematchBy = nil
+	g.turnStartedAt = time.Now()
 
 	for _, player := range g.Players {
 		player.Reset()
 	}
+	g.recordSetup()
 }
 
 func (g *Game) RefreshState() GameState {
-	if g.State == GameStateCancelled || g.State == GameStateStopped {
+	if g.State == GameStateCancelled || g.State == GameStateStopped || g.decidedOffBoard() {
 		return g.State
 	}
 
 	g.State = GameStateOngoing
 	if g.Board.IsFull() {
 		g.State = GameStateDraw
+		g.Result = ResultBoardFull
 	}
 	for _, player := range g.Players {
 		if g.Board.CheckWin(player.Token()) {
 			g.State = GameStateWin
+			g.Result = ResultConnectFour
 			g.Winner = player
 			player.IncWins()
 			break
@@ -76,6 +146,28 @@ func (g *Game) RefreshState() GameState {
 	return g.State
 }
 
+// decidedOffBoard reports whether the game ended by resignation or agreement, the board alone
+// can't tell these games are over.
+func (g *Game) decidedOffBoard() bool {
+	return g.Result == ResultResignation || g.Result == ResultAgreement
+}
+
+// ResultMessage describes how a finished game ended, it's empty while the game is undecided.
+func (g *Game) ResultMessage() string {
+	switch g.Result {
+	case ResultConnectFour:
+		return fmt.Sprintf("%s connected four", g.Winner.Name())
+	case ResultBoardFull:
+		return "Draw, the board is full"
+	case ResultResignation:
+		return fmt.Sprintf("%s resigned, %s wins", g.ResignedBy.Name(), g.Winner.Name())
+	case ResultAgreement:
+		return "Draw by agreement"
+	default:
+		return ""
+	}
+}
+
 func (g *Game) HasHuman() bool {
 	for _, player := range g.Players {
 		if _, isHuman := player.(*HumanPlayer); isHuman {
@@ -94,6 +186,9 @@ func (g *Game) Stop() {
 }
 
 func (g *Game) Resume() {
+	if g.decidedOffBoard() {
+		return
+	}
 	g.State = GameStateNew
 	g.RefreshState()
 }
@@ -116,11 +211,137 @@ func (g *Game) CurrentPlayer() Player {
 
 func (g *Game) NextPlayer() Player {
 	g.currentPlayerIdx = 1 - g.currentPlayerIdx // Toggle between 0 and 1
+	g.turnStartedAt = time.Now()
 	return g.CurrentPlayer()
 }
 
 func (g *Game) IncMoveCount() { g.MoveCount++ }
 
+// FindPlayer returns the player in the game with the given ID.
+func (g *Game) FindPlayer(id string) (Player, bool) {
+	for _, player := range g.Players {
+		if player.ID() == id {
+			return player, true
+		}
+	}
+	return nil, false
+}
+
+// Opponent returns the other player in the game, or nil if the player isn't in the game.
+func (g *Game) Opponent(player Player) Player {
+	switch player {
+	case g.Players[0]:
+		return g.Players[1]
+	case g.Players[1]:
+		return g.Players[0]
+	default:
+		return nil
+	}
+}
+
+// Resign ends the game as a win for the players opponent.
+func (g *Game) Resign(player Player) error {
+	if !g.InProgress() {
+		return ErrGameOver
+	}
+	opponent := g.Opponent(player)
+	if opponent == nil {
+		return ErrNotInGame
+	}
+
+	g.State = GameStateWin
+	g.Result = ResultResignation
+	g.Winner = opponent
+	g.ResignedBy = player
+	g.DrawOfferedBy = nil
+	opponent.IncWins()
+	return nil
+}
+
+// OfferDraw offers the players opponent a draw, the offer stands until it's answered or a move is played.
+func (g *Game) OfferDraw(player Player) error {
+	if !g.InProgress() {
+		return ErrGameOver
+	}
+	if g.Opponent(player) == nil {
+		return ErrNotInGame
+	}
+	g.DrawOfferedBy = player
+	return nil
+}
+
+// AcceptDraw ends the game as a draw, the player must be answering their opponents offer.
+func (g *Game) AcceptDraw(player Player) error {
+	if err := g.checkDrawOffer(player); err != nil {
+		return err
+	}
+	g.State = GameStateDraw
+	g.Result = ResultAgreement
+	g.DrawOfferedBy = nil
+	return nil
+}
+
+// DeclineDraw withdraws the opponents draw offer and play continues.
+func (g *Game) DeclineDraw(player Player) error {
+	if err := g.checkDrawOffer(player); err != nil {
+		return err
+	}
+	g.DrawOfferedBy = nil
+	return nil
+}
+
+func (g *Game) checkDrawOffer(player Player) error {
+	if !g.InProgress() {
+		return ErrGameOver
+	}
+	if g.Opponent(player) == nil {
+		return ErrNotInGame
+	}
+	if g.DrawOfferedBy == nil || g.DrawOfferedBy == player {
+		return ErrNoDrawOffer
+	}
+	return nil
+}
+
+// Play drops the players token in the given column, updates the game state and score, and
+// records the move. It does not advance the turn, callers are expected to call NextPlayer.
+func (g *Game) Play(player Player, col int) (Move, error) {
+	return g.play(player, col, time.Since(g.turnStartedAt))
+}
+
+func (g *Game) play(player Player, col int, thinkTime time.Duration) (Move, error) {
+	if player != g.CurrentPlayer() {
+		return Move{}, ErrNotPlayersTurn
+	}
+	if !g.InProgress() {
+		return Move{}, ErrGameOver
+	}
+	if col < 0 || g.Board.IsColumnFull(col) {
+		return Move{}, ErrInvalidMove
+	}
+
+	g.Board.Insert(player.Token(), col)
+	g.DrawOfferedBy = nil // playing on declines any standing offer
+	g.RefreshState()
+	g.IncMoveCount()
+
+	// update the players score
+	score := CalculateScore(player, g.Board)
+	player.AddScore(score)
+
+	move := Move{Column: col, PlayerID: player.ID(), ThinkTime: thinkTime, ScoreDelta: score, Config: g.configChange()}
+	g.Moves = append(g.Moves, move)
+	return move, nil
+}
+
+// LastMove returns the most recently played move.
+func (g *Game) LastMove() (Move, bool) {
+	if len(g.Moves) == 0 {
+		return Move{}, false
+	}
+	return g.Moves[len(g.Moves)-1], true
+}
+
 func CalculateScore(player Player, board *Board) uint64 {
 	// calculate the players score /100
 	opToken := tokenSwitch[player.Token()]
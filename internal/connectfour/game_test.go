@@ -0,0 +1,65 @@
+package connectfour
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGame_Resign(t *testing.T) {
+	player1, player2 := NewHumanPlayerPair()
+	game := NewGame(player1, player2)
+
+	if err := game.Resign(player1); err != nil {
+		t.Fatalf("failed to resign: %v", err)
+	}
+	if game.State != GameStateWin || game.Winner != player2 || game.ResignedBy != player1 || game.Result != ResultResignation {
+		t.Errorf("expected player 2 to win by resignation, got state %d result %q", game.State, game.Result)
+	}
+	if player2.Wins() != 1 {
+		t.Errorf("expected player 2 to have 1 win, got %d", player2.Wins())
+	}
+
+	// resuming must not undo the resignation
+	game.Resume()
+	if game.State != GameStateWin {
+		t.Errorf("expected resume to keep the resignation, got state %d", game.State)
+	}
+	if err := game.Resign(player2); !errors.Is(err, ErrGameOver) {
+		t.Errorf("expected ErrGameOver, got %v", err)
+	}
+}
+
+func TestGame_DrawOffer(t *testing.T) {
+	player1, player2 := NewHumanPlayerPair()
+	game := NewGame(player1, player2)
+
+	if err := game.AcceptDraw(player2); !errors.Is(err, ErrNoDrawOffer) {
+		t.Errorf("expected ErrNoDrawOffer without an offer, got %v", err)
+	}
+	if err := game.OfferDraw(player1); err != nil {
+		t.Fatalf("failed to offer draw: %v", err)
+	}
+	if err := game.AcceptDraw(player1); !errors.Is(err, ErrNoDrawOffer) {
+		t.Errorf("expected ErrNoDrawOffer accepting your own offer, got %v", err)
+	}
+	if err := game.DeclineDraw(player2); err != nil || game.DrawOfferedBy != nil {
+		t.Fatalf("expected the offer to be declined, got %v", err)
+	}
+
+	// playing a move lapses the offer
+	_ = game.OfferDraw(player1)
+	if _, err := game.Play(player1, 3); err != nil {
+		t.Fatalf("failed to play: %v", err)
+	}
+	if game.DrawOfferedBy != nil {
+		t.Errorf("expected the offer to lapse after a move")
+	}
+
+	_ = game.OfferDraw(player2)
+	if err := game.AcceptDraw(player1); err != nil {
+		t.Fatalf("failed to accept draw: %v", err)
+	}
+	if game.State != GameStateDraw || game.Result != ResultAgreement {
+		t.Errorf("expected a draw by agreement, got state %d result %q", game.State, game.Result)
+	}
+}
@@ -3,36 +3,53 @@ package connectfour
 import (
 	"log/slog"
 	"math"
-	"math/rand"
+	"strconv"
+
+	"github.com/Zach51920/connect-four/internal/metrics"
 )
 
 const (
 	MinimaxRandomnessFactor = 0.1
 	MinimaxDepthMultiplier  = 1
+
+	StrategyMinimax = "MINMAX"
 )
 
 func NewMinimaxBot(token rune) *BotPlayer {
-	config := DefaultConfig()
-	return &BotPlayer{
-		Config:     config,
-		strategy:   NewMinimaxStrat(config),
-		BasePlayer: NewBasePlayer(randomUsername(), token),
-	}
+	return NewMinimaxBotWithConfig(token, DefaultConfig())
+}
+
+func NewMinimaxBotWithConfig(token rune, config *Config) *BotPlayer {
+	return NewBotPlayer(randomUsername(config.Rand()), token, config, NewMinimaxStrat(config))
 }
 
 type MinimaxStrat struct {
 	Config *Config
+	depth  int // depth and positions searched for the last suggestion
+	nodes  int
+	// analysis searches aren't made by bots, they're left out of the bot metrics
+	analysis bool
 }
 
 func NewMinimaxStrat(config *Config) *MinimaxStrat {
 	return &MinimaxStrat{Config: config}
 }
 
-func (m *MinimaxStrat) Suggest(board *Board, token rune) int {
+func (m *MinimaxStrat) WithConfig(config *Config) Strategy {
+	return NewMinimaxStrat(config)
+}
+
+func (m *MinimaxStrat) Suggest(board *Board, token rune) Suggestion {
 	depth := m.Config.Difficulty * MinimaxDepthMultiplier
 	slog.Debug("Suggesting move", "depth", depth, "randomize", m.Config.Randomize)
+	m.depth, m.nodes = depth, 0
+	defer func() {
+		if !m.analysis {
+			metrics.NodesSearched.WithLabelValues(strconv.Itoa(m.Config.Difficulty)).Observe(float64(m.nodes))
+		}
+	}()
 
-	bestCol := -1
+	best := Suggestion{Column: -1}
 	bestScore := math.Inf(-1)
 	alpha := math.Inf(-1)
 	beta := math.Inf(1)
@@ -40,61 +57,74 @@ func (m *MinimaxStrat) Suggest(board *Board, token rune) int {
 
 	for _, col := range board.validColumns() {
 		tmpBoard := board.Copy().Insert(token, col)
-		score := m.Minimax(tmpBoard, token, opToken, depth, false, alpha, beta)
+		eval, pv := m.Minimax(tmpBoard, token, opToken, depth, false, alpha, beta)
 
-		// Add randomness, smarter bots are less random
+		// Add randomness, smarter bots are less random. The noise only picks between moves,
+		// the suggestion keeps the score the search found.
+		score := eval
 		if m.Config.Randomize {
 			randWeight := 1 - MinimaxRandomnessFactor*float64(m.Config.Difficulty)
-			score += rand.Float64() * randWeight
+			score += m.Config.Rand().Float64() * randWeight
 		}
 
 		if score > bestScore {
 			bestScore = score
-			bestCol = col
+			best = Suggestion{Column: col, Score: eval, PV: append([]int{col}, pv...)}
 		}
 		alpha = math.Max(alpha, score)
 		if beta <= alpha {
 			break
 		}
 	}
-	return bestCol
+	return best
 }
 
-func (m *MinimaxStrat) Minimax(board *Board, token, opToken rune, depth int, isMaximizing bool, alpha, beta float64) float64 {
+// Minimax scores the board for token and returns the line of play that leads to the score.
+func (m *MinimaxStrat) Minimax(board *Board, token, opToken rune, depth int, isMaximizing bool, alpha, beta float64) (float64, []int) {
+	m.nodes++
 	if depth == 0 || board.IsFull() || board.CheckWin(token) || board.CheckWin(opToken) {
-		return board.Evaluate(token, opToken)
+		return board.Evaluate(token, opToken), nil
 	}
 	validCols := board.validColumns()
 
+	var bestPV []int
 	if isMaximizing {
 		maxEval := math.Inf(-1)
 		for _, col := range validCols {
 			tmpBoard := board.Copy()
 			_ = tmpBoard.Insert(token, col)
-			eval := m.Minimax(tmpBoard, token, opToken, depth-1, false, alpha, beta)
-			maxEval = math.Max(maxEval, eval)
+			eval, pv := m.Minimax(tmpBoard, token, opToken, depth-1, false, alpha, beta)
+			if eval > maxEval {
+				maxEval, bestPV = eval, append([]int{col}, pv...)
+			}
 			alpha = math.Max(alpha, eval)
 			if beta <= alpha {
 				break
 			}
 		}
-		return maxEval
+		return maxEval, bestPV
 	} else {
 		minEval := math.Inf(1)
 		for _, col := range validCols {
 			tmpBoard := board.Copy()
 			_ = tmpBoard.Insert(opToken, col)
-			eval := m.Minimax(tmpBoard, token, opToken, depth-1, true, alpha, beta)
-			minEval = math.Min(minEval, eval)
+			eval, pv := m.Minimax(tmpBoard, token, opToken, depth-1, true, alpha, beta)
+			if eval < minEval {
+				minEval, bestPV = eval, append([]int{col}, pv...)
+			}
 			beta = math.Min(beta, eval)
 			if beta <= alpha {
 				break
 			}
 		}
-		return minEval
+		return minEval, bestPV
 	}
 }
 
+func (m *MinimaxStrat) LastSearch() (depth, nodes int) {
+	return m.depth, m.nodes
+}
+
 func (m *MinimaxStrat) Name() string {
-	return "MINMAX"
+	return StrategyMinimax
 }
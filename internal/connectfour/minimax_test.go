@@ -28,8 +28,54 @@ func BenchmarkMinimaxStrat_Suggest(b *testing.B) {
 	}
 }
 
+func TestBotGame_Deterministic(t *testing.T) {
+	play := func() []Move {
+		bot1 := NewMinimaxBotWithConfig('X', DefaultConfig().SetDifficulty(3).SetMistakeFrequency(90).SetSeed(1))
+		bot2 := NewMinimaxBotWithConfig('O', DefaultConfig().SetDifficulty(3).SetMistakeFrequency(90).SetSeed(2))
+		game := NewGame(bot1, bot2)
+		for game.RefreshState(); game.InProgress(); game.NextPlayer() {
+			bot := game.CurrentPlayer().(*BotPlayer)
+			if _, err := game.Play(bot, bot.Evaluate(game.Board)); err != nil {
+				t.Fatalf("bot made an invalid move: %v", err)
+			}
+		}
+		return game.Moves
+	}
+
+	first := play()
+	for i := 0; i < 5; i++ {
+		moves := play()
+		if len(moves) != len(first) {
+			t.Fatalf("run %d: expected %d moves, got %d", i, len(first), len(moves))
+		}
+		for j := range moves {
+			if moves[j].Column != first[j].Column {
+				t.Fatalf("run %d: move %d differs, expected column %d, got %d", i, j, first[j].Column, moves[j].Column)
+			}
+		}
+	}
+}
+
+func TestMinimaxStrat_Suggest(t *testing.T) {
+	board := NewBoard(DefaultBoardRows, DefaultBoardColumns)
+	for i, col := range []int{0, 6, 1, 6, 2, 5} {
+		board.Insert([]rune{'X', 'O'}[i%2], col)
+	}
+
+	suggestion := NewMinimaxStrat(DefaultConfig().SetDifficulty(4).IncludeRandomization(false)).Suggest(board, 'X')
+	if suggestion.Column != 3 {
+		t.Errorf("expected the winning column 3, got %d", suggestion.Column)
+	}
+	if suggestion.Score != WinScore {
+		t.Errorf("expected a score of %v, got %v", WinScore, suggestion.Score)
+	}
+	if len(suggestion.PV) == 0 || suggestion.PV[0] != suggestion.Column {
+		t.Errorf("expected the line to start with the suggested column, got %v", suggestion.PV)
+	}
+}
+
 func benchmarkSuggest(b *testing.B, board *Board, depth int) {
-	strat := NewMinimaxStrat(depth)
+	strat := NewMinimaxStrat(DefaultConfig().SetDifficulty(depth))
 	token := 'X'
 
 	b.ResetTimer()
@@ -41,9 +87,10 @@ func benchmarkSuggest(b *testing.B, board *Board, depth int) {
 func createHalfFullBoard() *Board {
 	board := NewBoard(DefaultBoardRows, DefaultBoardColumns)
 	token := 'X'
+	rng := rand.New(rand.NewSource(1))
 
 	for range DefaultBoardRows * DefaultBoardColumns / 2 {
-		col := board.validColumns()[rand.Intn(len(board.validColumns()))]
+		col := board.validColumns()[rng.Intn(len(board.validColumns()))]
 		board.Insert(token, col)
 		token = tokenSwitch[token]
 	}
@@ -53,9 +100,10 @@ func createHalfFullBoard() *Board {
 func createNearlyFullBoard() *Board {
 	board := NewBoard(DefaultBoardRows, DefaultBoardColumns)
 	token := 'X'
+	rng := rand.New(rand.NewSource(1))
 
 	for range DefaultBoardRows*DefaultBoardColumns - 5 {
-		col := board.validColumns()[rand.Intn(len(board.validColumns()))]
+		col := board.validColumns()[rng.Intn(len(board.validColumns()))]
 		board.Insert(token, col)
 		token = tokenSwitch[token]
 	}
@@ -0,0 +1,55 @@
+package connectfour
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ColumnName returns the column in standard notation, columns are lettered from 'a' on the left.
+func ColumnName(col int) string {
+	return string(rune('a' + col))
+}
+
+// ParseColumn parses a column in standard notation.
+func ParseColumn(name string) (int, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) != 1 || name[0] < 'a' || name[0] > 'z' {
+		return -1, fmt.Errorf("invalid column %q: %w", name, ErrInvalidMove)
+	}
+	return int(name[0] - 'a'), nil
+}
+
+// FormatColumns writes a sequence of columns as a move string, e.g. "ddcef".
+func FormatColumns(cols []int) string {
+	var sb strings.Builder
+	for _, col := range cols {
+		sb.WriteString(ColumnName(col))
+	}
+	return sb.String()
+}
+
+// FormatMoves writes the columns of the moves as a move string.
+func FormatMoves(moves []Move) string {
+	cols := make([]int, len(moves))
+	for i, move := range moves {
+		cols[i] = move.Column
+	}
+	return FormatColumns(cols)
+}
+
+// ParseColumns parses a move string into columns. Whitespace between moves is ignored.
+func ParseColumns(moves string) ([]int, error) {
+	cols := make([]int, 0, len(moves))
+	for _, r := range moves {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		col, err := ParseColumn(string(r))
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
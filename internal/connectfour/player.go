@@ -4,6 +4,8 @@ import (
 	"github.com/google/uuid"
 )
 
+const StrategyHuman = "HUMAN"
+
 type Player interface {
 	Name() string
 	ID() string
@@ -30,6 +32,7 @@ type BasePlayer struct {
 
 type HumanPlayer struct {
 	BasePlayer
+	userID string
 }
 
 func NewBasePlayer(name string, token rune) BasePlayer {
@@ -40,6 +43,15 @@ func NewHumanPlayer(name string, token rune) *HumanPlayer {
 	return &HumanPlayer{BasePlayer: NewBasePlayer(name, token)}
 }
 
+// NewUserPlayer creates a human player for a registered user, the player shares the users ID
+// so it stays the same across games.
+func NewUserPlayer(userID, name string, token rune) *HumanPlayer {
+	player := NewHumanPlayer(name, token)
+	player.id = userID
+	player.userID = userID
+	return player
+}
+
 func NewHumanPlayerPair() (*HumanPlayer, *HumanPlayer) {
 	player1 := NewHumanPlayer("Player1", 'X')
 	player2 := NewHumanPlayer("Player2", 'O')
@@ -64,8 +76,13 @@ func (p *BasePlayer) IncTurn() { p.turn++ }
 
 func (p *BasePlayer) Turn() int { return p.turn }
 
+func (p *BasePlayer) SetID(id string) *BasePlayer { p.id = id; return p }
+
 func (p *BasePlayer) SetToken(token rune) *BasePlayer { p.token = token; return p }
 
 func (p *BasePlayer) Token() rune { return p.token }
 
-func (p *HumanPlayer) Strategy() string { return "HUMAN" }
+func (p *HumanPlayer) Strategy() string { return StrategyHuman }
+
+// UserID is the ID of the account the player belongs to, empty for anonymous players.
+func (p *HumanPlayer) UserID() string { return p.userID }
@@ -0,0 +1,153 @@
+package connectfour
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrReplayMismatch = errors.New("replayed move does not match stored move")
+
+// PlayerSetup describes a player as it was at the start of a game. Config is only set for bots.
+type PlayerSetup struct {
+	ID       string
+	UserID   string
+	Name     string
+	Token    rune
+	Strategy string
+	Config   *Config
+}
+
+// GameSetup holds everything needed, along with the list of moves, to rebuild a game.
+type GameSetup struct {
+	ID      string
+	Variant string
+	Rows    int
+	Cols    int
+	Players [2]PlayerSetup
+}
+
+// Setup describes the game as it was created so it can be stored and later rebuilt with Replay,
+// bot config changes since then are on the moves.
+func (g *Game) Setup() GameSetup {
+	setup := GameSetup{
+		ID:      g.ID,
+		Variant: g.Variant,
+		Rows:    g.Board.NumRows(),
+		Cols:    g.Board.NumCols(),
+	}
+	for i, player := range g.Players {
+		setup.Players[i] = PlayerSetup{
+			ID:       player.ID(),
+			Name:     player.Name(),
+			Token:    player.Token(),
+			Strategy: player.Strategy(),
+		}
+		if human, ok := player.(*HumanPlayer); ok {
+			setup.Players[i].UserID = human.UserID()
+		}
+		if g.setupConfigs[i] != nil {
+			setup.Players[i].Config = g.setupConfigs[i].Copy()
+		}
+	}
+	return setup
+}
+
+// recordSetup remembers the bots configs as the game starts.
+func (g *Game) recordSetup() {
+	for i, player := range g.Players {
+		g.setupConfigs[i], g.playedConfigs[i] = nil, nil
+		if bot, ok := player.(*BotPlayer); ok {
+			g.setupConfigs[i] = bot.Config.Copy()
+			g.playedConfigs[i] = g.setupConfigs[i]
+		}
+	}
+}
+
+// configChange returns the current player's config if it's a bot whose config changed since it
+// last moved, or since the game started if it hasn't.
+func (g *Game) configChange() *Config {
+	bot, ok := g.CurrentPlayer().(*BotPlayer)
+	if !ok {
+		return nil
+	}
+	if played := g.playedConfigs[g.currentPlayerIdx]; played != nil && played.sameSettings(bot.Config) {
+		return nil
+	}
+	g.playedConfigs[g.currentPlayerIdx] = bot.Config.Copy()
+	return bot.Config.Copy()
+}
+
+// Replay rebuilds a game from its setup by playing every move in order. Each move must be
+// legal, made by the player whose turn it was, and award the same score it did originally.
+// The returned game can be resumed from where the moves leave off.
+func Replay(setup GameSetup, moves []Move) (*Game, error) {
+	var players [2]Player
+	for i, ps := range setup.Players {
+		player, err := ps.newPlayer()
+		if err != nil {
+			return nil, err
+		}
+		players[i] = player
+	}
+
+	rows, cols := setup.Rows, setup.Cols
+	if rows <= 0 || cols <= 0 {
+		rows, cols = DefaultBoardRows, DefaultBoardColumns
+	}
+
+	game := NewGame(players[0], players[1])
+	game.Board = NewBoard(rows, cols)
+	if setup.ID != "" {
+		game.ID = setup.ID
+	}
+	if setup.Variant != "" {
+		game.Variant = setup.Variant
+	}
+	if game.Variant != VariantStandard {
+		return nil, fmt.Errorf("unsupported variant: %s", game.Variant)
+	}
+
+	for i, move := range moves {
+		player := game.CurrentPlayer()
+		if move.PlayerID != player.ID() {
+			return nil, fmt.Errorf("move %d: %w", i+1, ErrNotPlayersTurn)
+		}
+		if bot, ok := player.(*BotPlayer); ok && move.Config != nil {
+			bot.Config.apply(move.Config)
+		}
+		played, err := game.play(player, move.Column, move.ThinkTime)
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		if move.ScoreDelta != 0 && played.ScoreDelta != move.ScoreDelta {
+			return nil, fmt.Errorf("move %d: score %d, stored %d: %w", i+1, played.ScoreDelta, move.ScoreDelta, ErrReplayMismatch)
+		}
+		game.NextPlayer()
+	}
+	return game, nil
+}
+
+func (ps PlayerSetup) newPlayer() (Player, error) {
+	var player Player
+	if ps.Strategy == StrategyHuman || ps.Strategy == "" {
+		human := NewHumanPlayer(ps.Name, ps.Token)
+		if ps.UserID != "" {
+			human = NewUserPlayer(ps.UserID, ps.Name, ps.Token)
+		}
+		human.SetID(ps.ID)
+		player = human
+	} else {
+		config := ps.Config
+		if config == nil {
+			config = DefaultConfig()
+		}
+		strategy, err := NewStrategy(ps.Strategy, config)
+		if err != nil {
+			return nil, err
+		}
+		bot := NewBotPlayer(ps.Name, ps.Token, config, strategy)
+		bot.SetID(ps.ID)
+		player = bot
+	}
+	return player, nil
+}
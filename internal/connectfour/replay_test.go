@@ -0,0 +1,88 @@
+package connectfour
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	player1, player2 := NewHumanPlayerPair()
+	game := NewGame(player1, player2)
+	for _, col := range []int{3, 3, 4, 4, 5, 5, 6} {
+		if _, err := game.Play(game.CurrentPlayer(), col); err != nil {
+			t.Fatalf("failed to play column %d: %v", col, err)
+		}
+		game.NextPlayer()
+	}
+
+	replayed, err := Replay(game.Setup(), game.Moves)
+	if err != nil {
+		t.Fatalf("failed to replay game: %v", err)
+	}
+	if replayed.State != GameStateWin || replayed.Winner.ID() != player1.ID() {
+		t.Errorf("expected player 1 to win the replayed game, got state %d", replayed.State)
+	}
+	if replayed.Players[0].Score() != player1.Score() || replayed.Players[1].Score() != player2.Score() {
+		t.Errorf("expected scores %d/%d, got %d/%d", player1.Score(), player2.Score(), replayed.Players[0].Score(), replayed.Players[1].Score())
+	}
+
+	tests := []struct {
+		name  string
+		moves []Move
+		err   error
+	}{
+		{"wrong player", []Move{{Column: 0, PlayerID: player2.ID()}}, ErrNotPlayersTurn},
+		{"out of bounds", []Move{{Column: 7, PlayerID: player1.ID()}}, ErrInvalidMove},
+		{"after win", append(append([]Move{}, game.Moves...), Move{Column: 0, PlayerID: player2.ID()}), ErrGameOver},
+		{"score mismatch", []Move{{Column: 0, PlayerID: player1.ID(), ScoreDelta: 1}}, ErrReplayMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Replay(game.Setup(), tt.moves); !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestReplay_BotConfig(t *testing.T) {
+	human := NewHumanPlayer("Player1", 'X')
+	bot := NewMinimaxBot('O')
+	bot.Config.SetDifficulty(2)
+	game := NewGame(human, bot)
+	for i, col := range []int{0, 1, 0, 1} {
+		if i == 2 {
+			bot.Config.SetDifficulty(4).SetSeed(7)
+		}
+		if _, err := game.Play(game.CurrentPlayer(), col); err != nil {
+			t.Fatalf("failed to play column %d: %v", col, err)
+		}
+		game.NextPlayer()
+	}
+	if game.Moves[1].Config != nil || game.Moves[3].Config == nil {
+		t.Fatalf("expected only the bot's move after the change to record it, got %+v", game.Moves)
+	}
+	if config := game.Setup().Players[1].Config; config.Difficulty != 2 {
+		t.Errorf("expected the setup to keep the starting difficulty, got %d", config.Difficulty)
+	}
+
+	tests := []struct {
+		name           string
+		ply            int
+		wantDifficulty int
+	}{
+		{"before the change", 2, 2},
+		{"after the change", 4, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replayed, err := Replay(game.Setup(), game.Moves[:tt.ply])
+			if err != nil {
+				t.Fatalf("failed to replay game: %v", err)
+			}
+			if got := replayed.Players[1].(*BotPlayer).Config.Difficulty; got != tt.wantDifficulty {
+				t.Errorf("expected difficulty %d, got %d", tt.wantDifficulty, got)
+			}
+		})
+	}
+}
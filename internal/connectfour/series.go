@@ -0,0 +1,147 @@
+package connectfour
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+const DefaultSeriesBestOf = 1
+
+var (
+	ErrGameInProgress = errors.New("game is still in progress")
+	ErrNoRematchOffer = errors.New("no rematch request to answer")
+	ErrInvalidSeries  = errors.New("series must be best of an odd number of games")
+)
+
+// SeriesResult is the outcome of one finished game in a series, WinnerID is empty for a draw.
+type SeriesResult struct {
+	GameID   string
+	WinnerID string
+}
+
+// Series is a best-of-N match between two players. The players swap who moves first every game.
+type Series struct {
+	ID        string
+	BestOf    int
+	PlayerIDs [2]string // in the seats of the first game
+	Results   []SeriesResult
+}
+
+func NewSeries(bestOf int, players [2]Player) (*Series, error) {
+	if bestOf < 1 || bestOf%2 == 0 {
+		return nil, ErrInvalidSeries
+	}
+	return &Series{
+		ID:        uuid.New().String(),
+		BestOf:    bestOf,
+		PlayerIDs: [2]string{players[0].ID(), players[1].ID()},
+	}, nil
+}
+
+// Record adds the result of a finished game, it reports false if the game was already recorded
+// or didn't finish with a result.
+func (s *Series) Record(game *Game) bool {
+	if game.State != GameStateWin && game.State != GameStateDraw {
+		return false
+	}
+	for _, result := range s.Results {
+		if result.GameID == game.ID {
+			return false
+		}
+	}
+
+	result := SeriesResult{GameID: game.ID}
+	if game.Winner != nil {
+		result.WinnerID = game.Winner.ID()
+	}
+	s.Results = append(s.Results, result)
+	return true
+}
+
+func (s *Series) Wins(playerID string) int {
+	wins := 0
+	for _, result := range s.Results {
+		if result.WinnerID == playerID {
+			wins++
+		}
+	}
+	return wins
+}
+
+func (s *Series) Draws() int {
+	return s.Wins("")
+}
+
+// WinnerID is the player who has clinched the series, or empty if nobody has yet.
+func (s *Series) WinnerID() string {
+	for _, id := range s.PlayerIDs {
+		if s.Wins(id) > s.BestOf/2 {
+			return id
+		}
+	}
+	return ""
+}
+
+// Over reports whether the series has been clinched or every game has been played.
+func (s *Series) Over() bool {
+	return s.WinnerID() != "" || len(s.Results) >= s.BestOf
+}
+
+// GameNumber is the number of the game currently being played, starting at 1.
+func (s *Series) GameNumber() int {
+	return len(s.Results) + 1
+}
+
+// SetBestOf starts a new series of the given length between the players.
+func (g *Game) SetBestOf(bestOf int) error {
+	series, err := NewSeries(bestOf, g.Players)
+	if err != nil {
+		return err
+	}
+	g.Series = series
+	return nil
+}
+
+// Rematch records the finished game in the series and starts the next one with the other player
+// moving first. Once the series is over the rematch starts a new series of the same length.
+func (g *Game) Rematch() error {
+	if g.InProgress() {
+		return ErrGameInProgress
+	}
+
+	g.Series.Record(g)
+	g.Players[0], g.Players[1] = g.Players[1], g.Players[0]
+	if g.Series.Over() {
+		series, err := NewSeries(g.Series.BestOf, g.Players)
+		if err != nil {
+			return err
+		}
+		g.Series = series
+	}
+	g.Restart()
+	return nil
+}
+
+// RequestRematch asks the players opponent for a rematch.
+func (g *Game) RequestRematch(player Player) error {
+	if g.InProgress() {
+		return ErrGameInProgress
+	}
+	if g.Opponent(player) == nil {
+		return ErrNotInGame
+	}
+	g.RematchBy = player
+	return nil
+}
+
+// AcceptRematch starts the rematch, the player must be answering their opponents request.
+func (g *Game) AcceptRematch(player Player) error {
+	if g.Opponent(player) == nil {
+		return ErrNotInGame
+	}
+	if g.RematchBy == nil || g.RematchBy == player {
+		return ErrNoRematchOffer
+	}
+	return g.Rematch()
+}
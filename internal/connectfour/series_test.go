@@ -0,0 +1,50 @@
+package connectfour
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGame_Rematch(t *testing.T) {
+	player1, player2 := NewHumanPlayerPair()
+	game := NewGame(player1, player2)
+	if err := game.SetBestOf(3); err != nil {
+		t.Fatalf("failed to set series length: %v", err)
+	}
+	if err := game.SetBestOf(2); !errors.Is(err, ErrInvalidSeries) {
+		t.Errorf("expected ErrInvalidSeries for an even series, got %v", err)
+	}
+	series := game.Series
+
+	if err := game.Rematch(); !errors.Is(err, ErrGameInProgress) {
+		t.Errorf("expected ErrGameInProgress, got %v", err)
+	}
+
+	// player 1 wins the first two games, alternating who starts
+	for i, starter := range []Player{player1, player2} {
+		if game.CurrentPlayer() != starter {
+			t.Fatalf("game %d: expected %s to start, got %s", i+1, starter.Name(), game.CurrentPlayer().Name())
+		}
+		loser := game.Opponent(player1)
+		if err := game.Resign(loser); err != nil {
+			t.Fatalf("game %d: failed to resign: %v", i+1, err)
+		}
+
+		if err := game.RequestRematch(player1); err != nil {
+			t.Fatalf("game %d: failed to request rematch: %v", i+1, err)
+		}
+		if err := game.AcceptRematch(player1); !errors.Is(err, ErrNoRematchOffer) {
+			t.Errorf("game %d: expected ErrNoRematchOffer accepting your own request, got %v", i+1, err)
+		}
+		if err := game.AcceptRematch(player2); err != nil {
+			t.Fatalf("game %d: failed to accept rematch: %v", i+1, err)
+		}
+	}
+
+	if series.Wins(player1.ID()) != 2 || series.WinnerID() != player1.ID() || !series.Over() {
+		t.Errorf("expected player 1 to clinch the series, got %+v", series.Results)
+	}
+	if game.Series == series || game.Series.GameNumber() != 1 {
+		t.Errorf("expected a new series to start once the last one was clinched")
+	}
+}
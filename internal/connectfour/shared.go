@@ -0,0 +1,91 @@
+package connectfour
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// SharedGame guards a game that's used from several goroutines, like a web session's request
+// handlers, its stream and the bot runner. Commands run one at a time, while snapshots can be
+// taken at any point to read the game without holding a lock. Bot searches run alongside both.
+type SharedGame struct {
+	searchMu sync.Mutex   // held by searches so a bot's random source is used by one at a time
+	stateMu  sync.RWMutex // held while the game is changed or copied
+	game     *Game
+}
+
+func NewSharedGame(game *Game) *SharedGame {
+	return &SharedGame{game: game}
+}
+
+// Update runs a command against the game. The game must not be kept after fn returns.
+func (s *SharedGame) Update(fn func(game *Game) error) error {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return fn(s.game)
+}
+
+// Snapshot returns a copy of the game that's safe to read while the game carries on.
+func (s *SharedGame) Snapshot() *Game {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.game.Snapshot()
+}
+
+// Search runs a search for the current player's move. fn gets a snapshot of the game to search
+// and the player, a bot is a copy with its own config so it can be reconfigured mid-search.
+// Commands don't wait for the search, so callers must check the game is still at the position
+// that was searched before playing the result.
+func (s *SharedGame) Search(fn func(player Player, snapshot *Game)) {
+	s.searchMu.Lock()
+	defer s.searchMu.Unlock()
+	s.stateMu.Lock()
+	player, snapshot := s.game.CurrentPlayer(), s.game.Snapshot()
+	if bot, ok := player.(*BotPlayer); ok {
+		player = bot.searcher()
+	}
+	s.stateMu.Unlock()
+	fn(player, snapshot)
+}
+
+// Snapshot deep copies the game. Players are copied too, the copies stand in for the originals
+// everywhere in the snapshot so comparing players still works.
+func (g *Game) Snapshot() *Game {
+	snapshot := *g
+	snapshot.Board = g.Board.Copy()
+	snapshot.Moves = slices.Clone(g.Moves)
+	snapshot.RatingChanges = maps.Clone(g.RatingChanges)
+	if g.Series != nil {
+		series := *g.Series
+		series.Results = slices.Clone(g.Series.Results)
+		snapshot.Series = &series
+	}
+
+	copies := make(map[Player]Player, len(g.Players))
+	for i, player := range g.Players {
+		copies[player] = copyPlayer(player)
+		snapshot.Players[i] = copies[player]
+	}
+	snapshot.Winner = copies[g.Winner]
+	snapshot.ResignedBy = copies[g.ResignedBy]
+	snapshot.DrawOfferedBy = copies[g.DrawOfferedBy]
+	snapshot.RematchBy = copies[g.RematchBy]
+	return &snapshot
+}
+
+// copyPlayer copies the players known to this package, a bot's copy gets its own config but
+// shares its strategy so it shouldn't be used to search.
+func copyPlayer(player Player) Player {
+	switch p := player.(type) {
+	case *HumanPlayer:
+		human := *p
+		return &human
+	case *BotPlayer:
+		bot := *p
+		bot.Config = p.Config.Copy()
+		return &bot
+	default:
+		return player
+	}
+}
@@ -0,0 +1,146 @@
+package connectfour
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGame_Snapshot(t *testing.T) {
+	player1, player2 := NewHumanPlayerPair()
+	game := NewGame(player1, player2)
+	if _, err := game.Play(player1, 3); err != nil {
+		t.Fatalf("failed to play: %v", err)
+	}
+	game.NextPlayer()
+	if err := game.OfferDraw(player2); err != nil {
+		t.Fatalf("failed to offer draw: %v", err)
+	}
+
+	snapshot := game.Snapshot()
+	if snapshot.Players[1] == game.Players[1] || snapshot.DrawOfferedBy != snapshot.Players[1] {
+		t.Errorf("expected the snapshot to refer to its own copy of player 2")
+	}
+	if snapshot.CurrentPlayer() != snapshot.Players[1] {
+		t.Errorf("expected player 2 to be the current player in the snapshot")
+	}
+
+	// changing the game must not change the snapshot
+	game.Restart()
+	if _, _, played := snapshot.Board.LastMove(); !played || len(snapshot.Moves) != 1 {
+		t.Errorf("expected the snapshot to keep its move, got %d", len(snapshot.Moves))
+	}
+}
+
+// TestSharedGame hammers a shared game from several goroutines, it's meant to be run with -race.
+func TestSharedGame(t *testing.T) {
+	bot1, bot2 := NewMinimaxBot('X'), NewMinimaxBot('O')
+	for _, bot := range []*BotPlayer{bot1, bot2} {
+		bot.Config.SetDifficulty(1).SetMistakeFrequency(0)
+	}
+	shared := NewSharedGame(NewGame(bot1, bot2))
+
+	const rounds = 50
+	var wg sync.WaitGroup
+	run := func(fn func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rounds {
+				fn(i)
+			}
+		}()
+	}
+
+	// moves
+	run(func(int) {
+		var col, plies int
+		var botID string
+		shared.Search(func(current Player, snapshot *Game) {
+			if bot, ok := current.(*BotPlayer); ok && snapshot.InProgress() {
+				botID, plies, col = bot.ID(), len(snapshot.Moves), bot.Evaluate(snapshot.Board)
+			}
+		})
+		_ = shared.Update(func(game *Game) error {
+			player := game.CurrentPlayer()
+			if botID == "" || player.ID() != botID || len(game.Moves) != plies {
+				return nil
+			}
+			if _, err := game.Play(player, col); err != nil {
+				return err
+			}
+			game.NextPlayer()
+			return nil
+		})
+	})
+	// restart, stop and resume
+	run(func(i int) {
+		_ = shared.Update(func(game *Game) error {
+			switch i % 3 {
+			case 0:
+				game.Restart()
+			case 1:
+				game.Stop()
+			default:
+				game.Resume()
+			}
+			return nil
+		})
+	})
+	// configure
+	run(func(i int) {
+		_ = shared.Update(func(game *Game) error {
+			game.Players[i%2].(*BotPlayer).Config.SetDifficulty(1 + i%2).SetSeed(int64(i))
+			return nil
+		})
+	})
+	// render
+	run(func(int) {
+		snapshot := shared.Snapshot()
+		_, _, _ = snapshot.Board.LastMove()
+		_ = snapshot.CurrentPlayer().Name()
+		_ = snapshot.Players[0].(*BotPlayer).Config.Difficulty
+	})
+	wg.Wait()
+}
+
+func TestSharedGame_Search(t *testing.T) {
+	bot1, bot2 := NewMinimaxBot('X'), NewMinimaxBot('O')
+	bot1.Config.SetDifficulty(3)
+	shared := NewSharedGame(NewGame(bot1, bot2))
+
+	searching, release := make(chan struct{}), make(chan struct{})
+	done := make(chan int)
+	go func() {
+		shared.Search(func(player Player, _ *Game) {
+			close(searching)
+			<-release
+			done <- player.(*BotPlayer).Config.Difficulty
+		})
+	}()
+	<-searching
+
+	// commands don't wait for the search, reconfiguring the bot leaves the search alone
+	updated := make(chan struct{})
+	go func() {
+		_ = shared.Update(func(game *Game) error {
+			game.Players[0].(*BotPlayer).Config.SetDifficulty(8)
+			game.Stop()
+			return nil
+		})
+		close(updated)
+	}()
+	select {
+	case <-updated:
+	case <-time.After(time.Second):
+		t.Fatal("expected the command to run during the search")
+	}
+
+	close(release)
+	if difficulty := <-done; difficulty != 3 {
+		t.Errorf("expected the search to keep difficulty 3, got %d", difficulty)
+	}
+	if bot1.Config.Difficulty != 8 {
+		t.Errorf("expected the bot to be reconfigured, got difficulty %d", bot1.Config.Difficulty)
+	}
+}
@@ -0,0 +1,317 @@
+package engine
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+const (
+	DefaultMoveTime = 5 * time.Second
+
+	// how long the engine gets to answer anything that isn't a search
+	defaultResponseTimeout = 2 * time.Second
+)
+
+var (
+	ErrEngineTimeout = errors.New("engine did not answer in time")
+	ErrEngineClosed  = errors.New("engine has exited")
+	ErrIllegalMove   = errors.New("engine suggested an illegal move")
+)
+
+// Engine is a strategy played by an external engine speaking the protocol. If the engine fails
+// to answer with a legal move in time, the most central open column is played instead.
+type Engine struct {
+	Config *connectfour.Config
+
+	name    string
+	author  string
+	options map[string]bool
+	w       io.Writer
+	lines   <-chan string
+	closer  func() error
+	mu      sync.Mutex // held for a whole exchange with the engine
+	err     error
+
+	// stateMu guards what can be read or changed while a search is running, it's never held
+	// while waiting on the engine
+	stateMu  sync.Mutex
+	moveTime time.Duration
+	lastInfo Info
+
+	responseTimeout time.Duration
+}
+
+// Start runs the engine at path and completes the handshake.
+func Start(config *connectfour.Config, path string, args ...string) (*Engine, error) {
+	cmd := exec.Command(path, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open engine stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open engine stdout: %w", err)
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start engine: %w", err)
+	}
+
+	closer := func() error {
+		_ = stdin.Close()
+		exited := make(chan error, 1)
+		go func() { exited <- cmd.Wait() }()
+		select {
+		case err := <-exited:
+			return err
+		case <-time.After(defaultResponseTimeout):
+			_ = cmd.Process.Kill()
+			return <-exited
+		}
+	}
+	engine, err := NewEngine(config, stdout, stdin, closer)
+	if err != nil {
+		_ = closer()
+		return nil, err
+	}
+	return engine, nil
+}
+
+// NewEngine talks to an engine that is already running, closer is called by Close once the
+// engine has been told to quit.
+func NewEngine(config *connectfour.Config, r io.Reader, w io.Writer, closer func() error) (*Engine, error) {
+	lines := make(chan string, 64)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	e := &Engine{
+		Config:   config,
+		options:  make(map[string]bool),
+		w:        w,
+		lines:    lines,
+		closer:   closer,
+		moveTime: DefaultMoveTime,
+
+		responseTimeout: defaultResponseTimeout,
+	}
+	if err := e.handshake(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *Engine) handshake() error {
+	if err := e.send(CmdHandshake); err != nil {
+		return err
+	}
+	err := e.readUntil(RespHandshake, time.Now().Add(e.responseTimeout), func(fields []string) {
+		switch {
+		case fields[0] == RespID && len(fields) >= 3 && fields[1] == "name":
+			e.name = strings.Join(fields[2:], " ")
+		case fields[0] == RespID && len(fields) >= 3 && fields[1] == "author":
+			e.author = strings.Join(fields[2:], " ")
+		case fields[0] == RespOption && len(fields) >= 3 && fields[1] == "name":
+			e.options[fields[2]] = true
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("handshake failed: %w", err)
+	}
+	if e.name == "" {
+		e.name = "engine"
+	}
+
+	// only set the options the engine asked for
+	options := map[string]string{
+		OptionDifficulty: strconv.Itoa(e.Config.Difficulty),
+		OptionRandomize:  strconv.FormatBool(e.Config.Randomize),
+		OptionSeed:       strconv.FormatInt(e.Config.Seed, 10),
+	}
+	for name, value := range options {
+		if !e.options[name] {
+			continue
+		}
+		if err = e.send(fmt.Sprintf("%s name %s value %s", CmdSetOption, name, value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Engine) Name() string { return e.name }
+
+// Author is the engine's author, if it said.
+func (e *Engine) Author() string { return e.author }
+
+// LastInfo returns the last info the engine reported while searching.
+func (e *Engine) LastInfo() Info {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	return e.lastInfo
+}
+
+// MoveTime is how long the engine is given for each move.
+func (e *Engine) MoveTime() time.Duration {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	return e.moveTime
+}
+
+// SetMoveTime changes how long the engine is given for each move, from the next search on.
+// Zero or less lets the engine search for as long as it wants.
+func (e *Engine) SetMoveTime(d time.Duration) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	e.moveTime = d
+}
+
+// Suggest asks the engine for its best move, the score and PV come from the engine's last info
+// line if it reported one for the move.
+func (e *Engine) Suggest(board *connectfour.Board, token rune) connectfour.Suggestion {
+	col, info, err := e.search(board, token)
+	if err != nil {
+		slog.Warn("Engine failed to suggest a move, playing the fallback", "engine", e.name, "error", err)
+		return connectfour.Suggestion{Column: fallbackColumn(board)}
+	}
+	suggestion := connectfour.Suggestion{Column: col}
+	if len(info.PV) > 0 && info.PV[0] == col {
+		suggestion.Score, suggestion.PV = info.Score, info.PV
+	}
+	return suggestion
+}
+
+func (e *Engine) search(board *connectfour.Board, token rune) (int, Info, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return -1, Info{}, e.err
+	}
+
+	// make sure nothing from an earlier search is still waiting to be read
+	if err := e.send(CmdIsReady); err != nil {
+		return -1, Info{}, err
+	}
+	if err := e.readUntil(RespReady, time.Now().Add(e.responseTimeout), nil); err != nil {
+		return -1, Info{}, err
+	}
+
+	moveTime := e.MoveTime()
+	goCmd := CmdGo
+	if e.Config.Difficulty > 0 {
+		goCmd += fmt.Sprintf(" depth %d", e.Config.Difficulty)
+	}
+	if moveTime > 0 {
+		goCmd += fmt.Sprintf(" movetime %d", moveTime.Milliseconds())
+	}
+	if err := e.send(CmdPosition + " board " + EncodeBoard(board, token)); err != nil {
+		return -1, Info{}, err
+	}
+	if err := e.send(goCmd); err != nil {
+		return -1, Info{}, err
+	}
+
+	bestMove := ""
+	var info Info // only this search's info, lastInfo may be from an earlier one
+	onLine := func(fields []string) {
+		switch fields[0] {
+		case RespInfo:
+			info = ParseInfo(fields[1:])
+			e.stateMu.Lock()
+			e.lastInfo = info
+			e.stateMu.Unlock()
+		case RespBestMove:
+			if len(fields) >= 2 {
+				bestMove = fields[1]
+			}
+		}
+	}
+
+	deadline := time.Now().Add(moveTime + e.responseTimeout)
+	if moveTime <= 0 {
+		deadline = time.Time{}
+	}
+	err := e.readUntil(RespBestMove, deadline, onLine)
+	if errors.Is(err, ErrEngineTimeout) {
+		// give the engine one last chance to answer with what it has
+		if err = e.send(CmdStop); err != nil {
+			return -1, Info{}, err
+		}
+		err = e.readUntil(RespBestMove, time.Now().Add(e.responseTimeout), onLine)
+	}
+	if err != nil {
+		return -1, Info{}, err
+	}
+
+	col, err := connectfour.ParseColumn(bestMove)
+	if err != nil || col >= board.NumCols() || board.IsColumnFull(col) {
+		return -1, Info{}, fmt.Errorf("%w: %q", ErrIllegalMove, bestMove)
+	}
+	return col, info, nil
+}
+
+// readUntil reads lines until one starts with the given response, passing every line to onLine.
+// A zero deadline waits forever.
+func (e *Engine) readUntil(resp string, deadline time.Time, onLine func([]string)) error {
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		select {
+		case line, ok := <-e.lines:
+			if !ok {
+				e.err = ErrEngineClosed
+				return e.err
+			}
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			if onLine != nil {
+				onLine(fields)
+			}
+			if fields[0] == resp {
+				return nil
+			}
+		case <-timeout:
+			return ErrEngineTimeout
+		}
+	}
+}
+
+func (e *Engine) send(line string) error {
+	if _, err := fmt.Fprintln(e.w, line); err != nil {
+		e.err = ErrEngineClosed
+		return fmt.Errorf("%w: %w", ErrEngineClosed, err)
+	}
+	return nil
+}
+
+// Close tells the engine to quit and releases it.
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_ = e.send(CmdQuit)
+	e.err = ErrEngineClosed
+	if e.closer == nil {
+		return nil
+	}
+	return e.closer()
+}
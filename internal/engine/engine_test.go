@@ -0,0 +1,228 @@
+package engine
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+// pipeEngine connects an engine client to fn playing the engine side.
+func pipeEngine(t *testing.T, config *connectfour.Config, fn func(in io.Reader, out io.Writer)) *Engine {
+	t.Helper()
+	clientR, engineW := io.Pipe()
+	engineR, clientW := io.Pipe()
+	go func() {
+		fn(engineR, engineW)
+		_ = engineW.Close()
+	}()
+
+	e, err := NewEngine(config, clientR, clientW, clientW.Close)
+	if err != nil {
+		t.Fatalf("failed to start engine: %v", err)
+	}
+	t.Cleanup(func() { _ = e.Close() })
+	return e
+}
+
+func TestEngine_Suggest(t *testing.T) {
+	server, err := NewServer(connectfour.StrategyMinimax, connectfour.DefaultConfig().IncludeRandomization(false))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	config := connectfour.DefaultConfig().SetDifficulty(4).IncludeRandomization(false)
+	e := pipeEngine(t, config, func(in io.Reader, out io.Writer) { _ = server.Serve(in, out) })
+	if e.Name() != "c4engine MINMAX" {
+		t.Errorf("expected engine name from handshake, got %q", e.Name())
+	}
+
+	// 'R' has three in a row along the bottom, the engine should finish it whatever the tokens
+	board := connectfour.NewBoard(connectfour.DefaultBoardRows, connectfour.DefaultBoardColumns)
+	for _, col := range []int{0, 1, 2} {
+		board.Insert('R', col).Insert('Y', col)
+	}
+	suggestion := e.Suggest(board, 'R')
+	if suggestion.Column != 3 {
+		t.Errorf("expected the winning column d, got %s", connectfour.ColumnName(suggestion.Column))
+	}
+	if len(suggestion.PV) == 0 || suggestion.PV[0] != 3 || suggestion.Score != connectfour.WinScore {
+		t.Errorf("expected a winning score and a PV starting with d, got %+v", suggestion)
+	}
+	if info := e.LastInfo(); info.Depth == 0 || len(info.PV) == 0 || info.PV[0] != 3 {
+		t.Errorf("expected search info for column d, got %+v", info)
+	}
+}
+
+func TestEngine_LastInfoDuringSearch(t *testing.T) {
+	searching, release := make(chan struct{}), make(chan struct{})
+	e := pipeEngine(t, connectfour.DefaultConfig(), func(in io.Reader, out io.Writer) {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			switch {
+			case scanner.Text() == CmdHandshake:
+				_, _ = io.WriteString(out, "id name thinker\nc4iok\n")
+			case scanner.Text() == CmdIsReady:
+				_, _ = io.WriteString(out, "readyok\n")
+			case scanner.Text() == CmdQuit:
+				return
+			case strings.HasPrefix(scanner.Text(), CmdGo):
+				_, _ = io.WriteString(out, "info depth 3 pv d\n")
+				close(searching)
+				<-release
+				_, _ = io.WriteString(out, "bestmove d\n")
+			}
+		}
+	})
+	e.SetMoveTime(0)
+
+	board := connectfour.NewBoard(connectfour.DefaultBoardRows, connectfour.DefaultBoardColumns)
+	done := make(chan int)
+	go func() { done <- e.Suggest(board, 'X').Column }()
+	<-searching
+
+	// the info and settings can be read and changed while the engine is still thinking
+	deadline := time.After(time.Second)
+	for e.LastInfo().Depth != 3 {
+		select {
+		case <-deadline:
+			t.Fatal("expected the info line to be readable during the search")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	e.SetMoveTime(time.Second)
+	if got := e.MoveTime(); got != time.Second {
+		t.Errorf("expected the move time to be changed during the search, got %s", got)
+	}
+
+	close(release)
+	if col := <-done; col != 3 {
+		t.Errorf("expected column d, got %s", connectfour.ColumnName(col))
+	}
+}
+
+func TestEngine_Fallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+	}{
+		{"illegal move", "bestmove z"},
+		{"no answer", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := pipeEngine(t, connectfour.DefaultConfig(), func(in io.Reader, out io.Writer) {
+				scanner := bufio.NewScanner(in)
+				for scanner.Scan() {
+					switch scanner.Text() {
+					case CmdHandshake:
+						_, _ = io.WriteString(out, "id name broken\nc4iok\n")
+					case CmdIsReady:
+						_, _ = io.WriteString(out, "readyok\n")
+					case CmdQuit:
+						return
+					default:
+						if tt.answer != "" && strings.HasPrefix(scanner.Text(), CmdGo) {
+							_, _ = io.WriteString(out, tt.answer+"\n")
+						}
+					}
+				}
+			})
+			e.SetMoveTime(time.Millisecond)
+			e.responseTimeout = 50 * time.Millisecond
+
+			board := connectfour.NewBoard(connectfour.DefaultBoardRows, connectfour.DefaultBoardColumns)
+			if _, _, err := e.search(board, 'X'); err == nil {
+				t.Fatal("expected the search to fail")
+			}
+			if col := e.Suggest(board, 'X').Column; col != 3 {
+				t.Errorf("expected the fallback to play the center, got %d", col)
+			}
+		})
+	}
+}
+
+// slowStrategy takes its time over the first position it's given, then plays the column
+// matching the number of discs on the board.
+type slowStrategy struct {
+	delay time.Duration
+	calls int
+}
+
+func (s *slowStrategy) Name() string { return "SLOW" }
+
+func (s *slowStrategy) Suggest(board *connectfour.Board, _ rune) connectfour.Suggestion {
+	if s.calls++; s.calls == 1 {
+		time.Sleep(s.delay)
+	}
+	discs := 0
+	for _, row := range board.Cells {
+		for _, cell := range row {
+			if cell != 0 {
+				discs++
+			}
+		}
+	}
+	return connectfour.Suggestion{Column: discs}
+}
+
+func TestEngine_LateBestMove(t *testing.T) {
+	server := &Server{
+		config:   connectfour.DefaultConfig(),
+		strategy: &slowStrategy{delay: 750 * time.Millisecond},
+		board:    connectfour.NewBoard(connectfour.DefaultBoardRows, connectfour.DefaultBoardColumns),
+	}
+	e := pipeEngine(t, connectfour.DefaultConfig().SetDifficulty(1), func(in io.Reader, out io.Writer) { _ = server.Serve(in, out) })
+	e.SetMoveTime(10 * time.Millisecond)
+	e.responseTimeout = 300 * time.Millisecond
+
+	// the first search gives up before the engine answers
+	board := connectfour.NewBoard(connectfour.DefaultBoardRows, connectfour.DefaultBoardColumns)
+	if _, _, err := e.search(board, 'X'); !errors.Is(err, ErrEngineTimeout) {
+		t.Fatalf("expected the first search to time out, got %v", err)
+	}
+
+	// its late answer, column a, must not be taken for the answer to the next position
+	board.Insert('X', 3)
+	col, _, err := e.search(board, 'O')
+	if err != nil {
+		t.Fatalf("failed to search: %v", err)
+	}
+	if col != 1 {
+		t.Errorf("expected column b for the new position, got %s", connectfour.ColumnName(col))
+	}
+}
+
+func TestDecodeBoard(t *testing.T) {
+	tests := []struct {
+		name    string
+		board   string
+		wantErr bool
+	}{
+		{"empty", "......./.......", false},
+		{"stacked", "x....../o......", false},
+		{"floating", "x....../.......", true},
+		{"uneven rows", "....../.......", true},
+		{"unknown cell", "......./...r...", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board, err := DecodeBoard(tt.board)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPosition) {
+					t.Errorf("expected ErrInvalidPosition, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if encoded := EncodeBoard(board, TokenToMove); encoded != tt.board {
+				t.Errorf("expected %q to round trip, got %q", tt.board, encoded)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+// Package engine implements C4I, a line based protocol in the spirit of UCI for running
+// connect four engines as separate processes, in any language.
+//
+// The client drives the engine with these commands:
+//
+//	c4i                                  start the session, the engine answers with its id and option lines, then c4iok
+//	setoption name <name> value <value>  change one of the engine's options
+//	isready                              wait for the engine to catch up, it answers readyok
+//	newgame                              the next position is from a different game
+//	position board <rows>                set the position, see EncodeBoard
+//	position startpos [moves <moves>]    set the position by playing a move string on an empty 6x7 board
+//	go [depth <n>] [movetime <ms>]       search the position, the engine answers bestmove <column>
+//	stop                                 stop searching and answer bestmove as soon as possible
+//	quit                                 exit
+//
+// And the engine answers with:
+//
+//	id name <name>
+//	id author <author>
+//	option name <name> type <spin|check> default <value> [min <n> max <n>]
+//	c4iok
+//	readyok
+//	info depth <n> score <score> [time <ms>] [pv <moves>]
+//	info string <message>
+//	bestmove <column>
+//
+// Columns and move strings use standard notation, columns are lettered from 'a' on the left.
+// Scores are from the point of view of the side to move. Unknown commands and lines are
+// ignored by both sides.
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+const (
+	CmdHandshake = "c4i"
+	CmdSetOption = "setoption"
+	CmdIsReady   = "isready"
+	CmdNewGame   = "newgame"
+	CmdPosition  = "position"
+	CmdGo        = "go"
+	CmdStop      = "stop"
+	CmdQuit      = "quit"
+
+	RespID        = "id"
+	RespOption    = "option"
+	RespHandshake = "c4iok"
+	RespReady     = "readyok"
+	RespInfo      = "info"
+	RespBestMove  = "bestmove"
+
+	OptionDifficulty = "Difficulty"
+	OptionRandomize  = "Randomize"
+	OptionSeed       = "Seed"
+
+	// the side to move is always 'x' on the wire, whatever tokens the game uses
+	cellEmpty    = '.'
+	cellToMove   = 'x'
+	cellOpponent = 'o'
+
+	// tokens used for boards decoded from the wire
+	TokenToMove   = 'X'
+	TokenOpponent = 'O'
+)
+
+var ErrInvalidPosition = errors.New("invalid position")
+
+// Info is the engine's report on its search.
+type Info struct {
+	Depth int
+	Score float64
+	Time  time.Duration
+	PV    []int
+}
+
+func (i Info) String() string {
+	line := fmt.Sprintf("%s depth %d score %s time %d", RespInfo, i.Depth,
+		strconv.FormatFloat(i.Score, 'f', -1, 64), i.Time.Milliseconds())
+	if len(i.PV) > 0 {
+		line += " pv " + connectfour.FormatColumns(i.PV)
+	}
+	return line
+}
+
+// ParseInfo reads the fields of an info line, fields it doesn't know are skipped.
+func ParseInfo(fields []string) Info {
+	var info Info
+	for i := 0; i+1 < len(fields); i++ {
+		value := fields[i+1]
+		switch fields[i] {
+		case "depth":
+			info.Depth, _ = strconv.Atoi(value)
+		case "score":
+			info.Score, _ = strconv.ParseFloat(value, 64)
+		case "time":
+			ms, _ := strconv.Atoi(value)
+			info.Time = time.Duration(ms) * time.Millisecond
+		case "pv":
+			info.PV, _ = connectfour.ParseColumns(value)
+		default:
+			continue
+		}
+		i++
+	}
+	return info
+}
+
+// EncodeBoard writes the board as seen by the player with the given token. Rows are written top
+// first and separated by '/', empty cells are '.', the players stones are 'x' and the opponents 'o'.
+func EncodeBoard(board *connectfour.Board, token rune) string {
+	rows := make([]string, board.NumRows())
+	for row := range rows {
+		var sb strings.Builder
+		for col := 0; col < board.NumCols(); col++ {
+			switch board.GetCell(row, col) {
+			case 0:
+				sb.WriteRune(cellEmpty)
+			case token:
+				sb.WriteRune(cellToMove)
+			default:
+				sb.WriteRune(cellOpponent)
+			}
+		}
+		rows[row] = sb.String()
+	}
+	return strings.Join(rows, "/")
+}
+
+// DecodeBoard reads a board written by EncodeBoard, the side to move gets TokenToMove.
+func DecodeBoard(s string) (*connectfour.Board, error) {
+	rows := strings.Split(s, "/")
+	cols := len(rows[0])
+	if cols == 0 {
+		return nil, fmt.Errorf("empty board: %w", ErrInvalidPosition)
+	}
+	for _, row := range rows {
+		if len(row) != cols {
+			return nil, fmt.Errorf("rows have different lengths: %w", ErrInvalidPosition)
+		}
+	}
+
+	// fill each column from the bottom so the board keeps track of its heights
+	board := connectfour.NewBoard(len(rows), cols)
+	for col := 0; col < cols; col++ {
+		for row := len(rows) - 1; row >= 0; row-- {
+			var token rune
+			switch rows[row][col] {
+			case cellEmpty:
+				continue
+			case cellToMove:
+				token = TokenToMove
+			case cellOpponent:
+				token = TokenOpponent
+			default:
+				return nil, fmt.Errorf("unknown cell %q: %w", rows[row][col], ErrInvalidPosition)
+			}
+			if row+1 < len(rows) && rows[row+1][col] == cellEmpty {
+				return nil, fmt.Errorf("floating stone in column %s: %w", connectfour.ColumnName(col), ErrInvalidPosition)
+			}
+			board.Insert(token, col)
+		}
+	}
+	return board, nil
+}
+
+// parsePosition reads the arguments of a position command.
+func parsePosition(args []string) (*connectfour.Board, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing position: %w", ErrInvalidPosition)
+	}
+	switch args[0] {
+	case "board":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("missing board: %w", ErrInvalidPosition)
+		}
+		return DecodeBoard(args[1])
+	case "startpos":
+		var moves []int
+		if len(args) >= 3 && args[1] == "moves" {
+			var err error
+			if moves, err = connectfour.ParseColumns(args[2]); err != nil {
+				return nil, err
+			}
+		}
+
+		// stones alternate from the first player, whoever's turn it is now is to move
+		token := rune(TokenToMove)
+		if len(moves)%2 == 1 {
+			token = TokenOpponent
+		}
+		board := connectfour.NewBoard(connectfour.DefaultBoardRows, connectfour.DefaultBoardColumns)
+		for _, col := range moves {
+			if col >= board.NumCols() || board.IsColumnFull(col) {
+				return nil, fmt.Errorf("illegal move %s: %w", connectfour.ColumnName(col), ErrInvalidPosition)
+			}
+			board.Insert(token, col)
+			token = otherToken(token)
+		}
+		return board, nil
+	default:
+		return nil, fmt.Errorf("unknown position %q: %w", args[0], ErrInvalidPosition)
+	}
+}
+
+func otherToken(token rune) rune {
+	if token == TokenToMove {
+		return TokenOpponent
+	}
+	return TokenToMove
+}
+
+// fallbackColumn picks the most central column with space, or -1 on a full board.
+func fallbackColumn(board *connectfour.Board) int {
+	center := board.NumCols() / 2
+	for offset := 0; offset <= center; offset++ {
+		for _, col := range []int{center - offset, center + offset} {
+			if col >= 0 && col < board.NumCols() && !board.IsColumnFull(col) {
+				return col
+			}
+		}
+	}
+	return -1
+}
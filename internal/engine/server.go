@@ -0,0 +1,225 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+const (
+	maxDifficulty = 20
+
+	// an iteration is assumed to take this many times longer than the last, deepening stops
+	// when the next one wouldn't finish within the move time
+	branchingFactor = 4
+)
+
+// Server answers the protocol on behalf of one of the registered strategies.
+type Server struct {
+	Author string
+
+	mu       sync.Mutex
+	out      io.Writer
+	config   *connectfour.Config
+	strategy connectfour.Strategy
+	board    *connectfour.Board
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewServer creates a server for the named strategy, config holds the defaults for its options.
+func NewServer(strategy string, config *connectfour.Config) (*Server, error) {
+	strat, err := connectfour.NewStrategy(strategy, config)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		config:   config,
+		strategy: strat,
+		board:    connectfour.NewBoard(connectfour.DefaultBoardRows, connectfour.DefaultBoardColumns),
+	}, nil
+}
+
+// Serve reads commands until quit or the end of the input. A search in progress when the input
+// ends is left to finish so its bestmove is still written.
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	s.out = out
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		cmd, args := fields[0], fields[1:]
+		switch cmd {
+		case CmdHandshake:
+			s.handshake()
+		case CmdIsReady:
+			// readyok has to come after the bestmove of a search in progress, clients rely on
+			// it to skip the answers to searches they've given up on
+			s.wait()
+			s.send(RespReady)
+		case CmdStop:
+			s.stopSearch()
+		case CmdQuit:
+			s.stopSearch()
+			s.wait()
+			return nil
+		case CmdSetOption:
+			s.wait()
+			s.setOption(args)
+		case CmdNewGame:
+			s.wait()
+			s.board = connectfour.NewBoard(connectfour.DefaultBoardRows, connectfour.DefaultBoardColumns)
+		case CmdPosition:
+			s.wait()
+			board, err := parsePosition(args)
+			if err != nil {
+				s.send(fmt.Sprintf("info string %s", err.Error()))
+				continue
+			}
+			s.board = board
+		case CmdGo:
+			s.wait()
+			s.startSearch(args)
+		}
+	}
+	s.wait()
+	return scanner.Err()
+}
+
+func (s *Server) handshake() {
+	s.send(fmt.Sprintf("%s name c4engine %s", RespID, s.strategy.Name()))
+	if s.Author != "" {
+		s.send(fmt.Sprintf("%s author %s", RespID, s.Author))
+	}
+	s.send(fmt.Sprintf("%s name %s type spin default %d min 1 max %d", RespOption, OptionDifficulty, s.config.Difficulty, maxDifficulty))
+	s.send(fmt.Sprintf("%s name %s type check default %t", RespOption, OptionRandomize, s.config.Randomize))
+	s.send(fmt.Sprintf("%s name %s type spin default %d", RespOption, OptionSeed, s.config.Seed))
+	s.send(RespHandshake)
+}
+
+func (s *Server) setOption(args []string) {
+	// setoption name <name> value <value>
+	if len(args) < 4 || args[0] != "name" || args[2] != "value" {
+		return
+	}
+	switch args[1] {
+	case OptionDifficulty:
+		if difficulty, err := strconv.Atoi(args[3]); err == nil && difficulty >= 1 && difficulty <= maxDifficulty {
+			s.config.SetDifficulty(difficulty)
+		}
+	case OptionRandomize:
+		if randomize, err := strconv.ParseBool(args[3]); err == nil {
+			s.config.IncludeRandomization(randomize)
+		}
+	case OptionSeed:
+		if seed, err := strconv.ParseInt(args[3], 10, 64); err == nil {
+			s.config.SetSeed(seed)
+		}
+	}
+}
+
+// startSearch searches the current position in the background with iterative deepening,
+// reporting each finished depth until it runs out of depth or time, or is stopped.
+func (s *Server) startSearch(args []string) {
+	maxDepth := s.config.Difficulty
+	var moveTime time.Duration
+	for i := 0; i+1 < len(args); i += 2 {
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil {
+			continue
+		}
+		switch args[i] {
+		case "depth":
+			maxDepth = min(max(n, 1), maxDifficulty)
+		case "movetime":
+			moveTime = time.Duration(n) * time.Millisecond
+		}
+	}
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	s.mu.Lock()
+	s.stop, s.done = stop, done
+	s.mu.Unlock()
+
+	board := s.board.Copy()
+	difficulty := s.config.Difficulty
+	go func() {
+		defer close(done)
+		// the strategy reads its depth from the config, put it back once we're done
+		defer s.config.SetDifficulty(difficulty)
+
+		start := time.Now()
+		best := fallbackColumn(board)
+		for depth := 1; depth <= maxDepth && best != -1; depth++ {
+			iterStart := time.Now()
+			s.config.SetDifficulty(depth)
+			suggestion := s.strategy.Suggest(board, TokenToMove)
+			col := suggestion.Column
+			if col < 0 || col >= board.NumCols() || board.IsColumnFull(col) {
+				break
+			}
+			best = col
+
+			// strategies that don't score their moves get the move's static evaluation
+			score, pv := suggestion.Score, suggestion.PV
+			if len(pv) == 0 {
+				score = board.Copy().Insert(TokenToMove, col).Evaluate(TokenToMove, TokenOpponent)
+				pv = []int{col}
+			}
+			s.send(Info{Depth: depth, Score: score, Time: time.Since(start), PV: pv}.String())
+
+			if stopped(stop) || moveTime > 0 && time.Since(start)+time.Since(iterStart)*branchingFactor > moveTime {
+				break
+			}
+		}
+
+		if best == -1 {
+			s.send(RespBestMove + " none")
+			return
+		}
+		s.send(RespBestMove + " " + connectfour.ColumnName(best))
+	}()
+}
+
+func (s *Server) stopSearch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+func stopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// wait blocks until the search in progress, if any, has answered.
+func (s *Server) wait() {
+	s.mu.Lock()
+	done := s.done
+	s.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+func (s *Server) send(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = fmt.Fprintln(s.out, line)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/Zach51920/connect-four/internal/models"
+	"github.com/Zach51920/connect-four/internal/repository"
+	"github.com/Zach51920/connect-four/internal/services"
+	"github.com/Zach51920/connect-four/internal/views"
+	cookies "github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handlers) LoginPage(c *gin.Context) {
+	render(c, views.Login(""))
+}
+
+func (h *Handlers) Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBind(&req); err != nil {
+		slog.Error("Failed to bind LoginRequest", "error", err)
+		h.handleError(c, "An unexpected error has occurred")
+		return
+	}
+
+	user, err := h.users.Login(c.Request.Context(), req)
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		render(c, views.Login(err.Error()))
+		return
+	} else if err != nil {
+		slog.Error("Failed to log in", "error", err)
+		h.handleError(c, "An unexpected error has occurred")
+		return
+	}
+	h.signIn(c, user)
+}
+
+func (h *Handlers) Register(c *gin.Context) {
+	var req models.RegisterRequest
+	if err := c.ShouldBind(&req); err != nil {
+		slog.Error("Failed to bind RegisterRequest", "error", err)
+		h.handleError(c, "An unexpected error has occurred")
+		return
+	}
+
+	user, err := h.users.Register(c.Request.Context(), req)
+	switch {
+	case errors.Is(err, services.ErrInvalidUsername), errors.Is(err, services.ErrInvalidPassword),
+		errors.Is(err, services.ErrInvalidDisplayName), errors.Is(err, repository.ErrUsernameTaken):
+		render(c, views.Login(err.Error()))
+		return
+	case err != nil:
+		slog.Error("Failed to register user", "error", err)
+		h.handleError(c, "An unexpected error has occurred")
+		return
+	}
+	h.signIn(c, user)
+}
+
+func (h *Handlers) Logout(c *gin.Context) {
+	s := cookies.Default(c)
+	s.Delete("user_id")
+	if err := s.Save(); err != nil {
+		slog.Warn("failed to clear user ID from cookie store", "error", err)
+	}
+	c.Set("user_id", "")
+	h.Home(c)
+}
+
+func (h *Handlers) Profile(c *gin.Context) {
+	user := h.currentUser(c)
+	if user == nil {
+		render(c, views.Login("Log in to view your profile"))
+		return
+	}
+	render(c, views.Profile(user, ""))
+}
+
+func (h *Handlers) UpdateProfile(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		render(c, views.Login("Log in to view your profile"))
+		return
+	}
+
+	var req models.ProfileRequest
+	if err := c.ShouldBind(&req); err != nil {
+		slog.Error("Failed to bind ProfileRequest", "error", err)
+		h.handleError(c, "An unexpected error has occurred")
+		return
+	}
+
+	user, err := h.users.UpdateProfile(c.Request.Context(), userID, req)
+	if errors.Is(err, services.ErrInvalidDisplayName) {
+		render(c, views.Profile(h.currentUser(c), err.Error()))
+		return
+	} else if err != nil {
+		slog.Error("Failed to update profile", "user_id", userID, "error", err)
+		h.handleError(c, "Failed to update profile")
+		return
+	}
+	render(c, views.Profile(user, "Profile saved"))
+}
+
+// signIn links the users account to the browser session and sends them home.
+func (h *Handlers) signIn(c *gin.Context, user *repository.User) {
+	s := cookies.Default(c)
+	s.Set("user_id", user.ID)
+	if err := s.Save(); err != nil {
+		slog.Error("failed to save user ID to cookie store", "error", err)
+		h.handleError(c, "Failed to log in")
+		return
+	}
+	c.Set("user_id", user.ID)
+	slog.Info("User signed in", "user_id", user.ID, "session_id", c.GetString("session_id"))
+	h.Home(c)
+}
+
+// currentUser returns the signed in user, or nil for anonymous sessions.
+func (h *Handlers) currentUser(c *gin.Context) *repository.User {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		return nil
+	}
+	user, err := h.users.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		slog.Warn("Failed to get signed in user", "user_id", userID, "error", err)
+		return nil
+	}
+	return user
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/models"
+	"github.com/Zach51920/connect-four/internal/repository"
+	"github.com/Zach51920/connect-four/internal/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"log/slog"
+	"net/http"
+)
+
+// the API is open to any origin, the same as the CORS config
+var upgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+// GameSocket lets API clients queue for and play online games over a websocket. Clients send
+// models.APIRequest messages and receive models.APIMessage messages.
+func (h *Handlers) GameSocket(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		slog.Error("Failed to upgrade websocket", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// every connection gets its own session so it can't clash with the browser
+	sess := h.sessions.New(uuid.New().String(), nil)
+	user := h.currentUser(c)
+	slog.Info("API client connected", "session_id", sess.ID)
+
+	ctx, ctxCancel := context.WithCancel(c.Request.Context())
+	defer ctxCancel()
+	defer h.disconnect(sess)
+
+	// requests are handled as they're read, everything written to the client goes through the
+	// session so there's only ever one writer
+	go func() {
+		defer ctxCancel()
+		for {
+			var req models.APIRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					slog.Debug("Failed to read API request", "session_id", sess.ID, "error", err)
+				}
+				return
+			}
+			if err := h.handleAPIRequest(ctx, sess, user, req); err != nil {
+				sess.Send(models.MessageError, models.APIError{Message: err.Error()})
+			}
+		}
+	}()
+
+	write := func(messageType string, data any) {
+		msg, err := models.NewAPIMessage(messageType, data)
+		if err != nil {
+			slog.Error("Failed to encode API message", "type", messageType, "error", err)
+			return
+		}
+		if err = conn.WriteJSON(msg); err != nil {
+			slog.Debug("Failed to write API message", "session_id", sess.ID, "error", err)
+			ctxCancel()
+		}
+	}
+	sess.Listen(ctx,
+		func() { write(models.MessageState, models.NewGameState(sess.Game().Snapshot())) },
+		func(e sessions.Event) { write(e.Name, e.Data) },
+	)
+}
+
+var (
+	errNoGame      = errors.New("no active game")
+	errNotYourTurn = errors.New("not your turn")
+	errBadRequest  = errors.New("bad request")
+	errInvalidMove = errors.New("invalid move")
+	errNoPlayer    = errors.New("no player to act for")
+	errNoDrawOffer = errors.New("no draw offer to answer")
+)
+
+func (h *Handlers) handleAPIRequest(ctx context.Context, sess *sessions.Session, user *repository.User, req models.APIRequest) error {
+	switch req.Type {
+	case models.MessageJoin:
+		sess.SetGame(nil)
+		status, err := h.matchmaking.Join(ctx, sess, user, models.MatchmakingRequest{TimeControl: req.TimeControl, Name: req.Name})
+		if err != nil {
+			return err
+		}
+		sess.Send(models.EventQueueUpdate, status)
+	case models.MessageLeave:
+		h.matchmaking.Leave(sess.ID)
+	case models.MessageMove:
+		if sess.Game() == nil {
+			return errNoGame
+		}
+		shared := sess.Game()
+		err := shared.Update(func(game *connectfour.Game) error {
+			player := game.CurrentPlayer()
+			if _, ok := player.(*connectfour.HumanPlayer); !ok || !sess.Controls(player) {
+				return errNotYourTurn
+			}
+			if err := h.service.MakeMove(ctx, player, game, req.Column); err != nil {
+				return err
+			}
+			game.NextPlayer()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		h.service.RateGame(ctx, shared)
+		refresh(sess)
+	case models.MessageResign:
+		if sess.Game() == nil {
+			return errNoGame
+		}
+		shared := sess.Game()
+		err := shared.Update(func(game *connectfour.Game) error {
+			return h.service.Resign(ctx, game, actingPlayer(sess, game))
+		})
+		if err != nil {
+			return err
+		}
+		h.service.RateGame(ctx, shared)
+		refresh(sess)
+	default:
+		return errors.New("unknown request type: " + req.Type)
+	}
+	return nil
+}
+
+// disconnect takes a closed connection out of the queue and abandons its game.
+func (h *Handlers) disconnect(sess *sessions.Session) {
+	slog.Info("API client disconnected", "session_id", sess.ID)
+	h.matchmaking.Leave(sess.ID)
+	if sess.Game() != nil {
+		h.abort(sess)
+	}
+}
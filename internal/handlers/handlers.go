@@ -1,39 +1,70 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/metrics"
 	"github.com/Zach51920/connect-four/internal/models"
+	"github.com/Zach51920/connect-four/internal/repository"
 	"github.com/Zach51920/connect-four/internal/services"
 	"github.com/Zach51920/connect-four/internal/sessions"
 	"github.com/Zach51920/connect-four/internal/views"
 	"github.com/a-h/templ"
 	"github.com/gin-gonic/gin"
 	"log/slog"
-	"time"
+	"net/http"
 )
 
 type Handlers struct {
-	sessions sessions.Store
-	service  *services.GameService
+	sessions    sessions.Store
+	service     *services.GameService
+	users       *services.UserService
+	matchmaking *services.MatchmakingService
+	bots        *services.BotRunner
+	publicURL   string
 }
 
-func New(service *services.GameService) *Handlers {
+func New(store sessions.Store, service *services.GameService, users *services.UserService, matchmaking *services.MatchmakingService, bots *services.BotRunner) *Handlers {
 	return &Handlers{
-		sessions: sessions.NewMemorySessionStore(),
-		service:  service,
+		sessions:    store,
+		service:     service,
+		users:       users,
+		matchmaking: matchmaking,
+		bots:        bots,
 	}
 }
 
+// SetPublicURL sets the scheme and host the site is served from, shared pages link to
+// themselves with it. Requests can't be trusted to say where they were sent.
+func (h *Handlers) SetPublicURL(publicURL string) {
+	h.publicURL = publicURL
+}
+
 func (h *Handlers) Home(c *gin.Context) {
 	// if there's an active game, cancel it
 	sessionID := c.GetString("session_id")
 	sess, _ := h.sessions.Get(sessionID)
-	if sess != nil && sess.Game != nil && sess.Game.InProgress() {
+	if sess != nil && sess.Game() != nil && h.abort(sess) {
 		render(c, views.WarningToast("The active game has been aborted"))
-		sess.Game.Cancel()
 	}
 	// render the home page
-	render(c, views.Home())
+	render(c, views.Home(h.currentUser(c)))
+}
+
+// abort cancels the session's game if it's in progress, it reports whether there was one.
+func (h *Handlers) abort(sess *sessions.Session) bool {
+	h.bots.Cancel(sess.ID)
+	var aborted bool
+	_ = sess.Game().Update(func(game *connectfour.Game) error {
+		if aborted = game.InProgress(); aborted {
+			game.Cancel()
+		}
+		return nil
+	})
+	if aborted {
+		refresh(sess)
+	}
+	return aborted
 }
 
 func (h *Handlers) CreateGame(c *gin.Context) {
@@ -43,6 +74,7 @@ func (h *Handlers) CreateGame(c *gin.Context) {
 	if !ok || sess == nil {
 		sess = h.sessions.New(sessionID, nil)
 	}
+	h.matchmaking.Leave(sessionID)
 
 	// parse the request
 	var req models.CreateGameRequest
@@ -52,15 +84,16 @@ func (h *Handlers) CreateGame(c *gin.Context) {
 	}
 
 	// create the game and add assign it to our session
-	game, err := h.service.CreateGame(req)
+	game, err := h.service.CreateGame(req, h.currentUser(c))
 	if err != nil {
 		h.handleCriticalErr(c, "Failed to create game")
 		return
 	}
+	h.bots.Cancel(sess.ID)
 	sess.SetGame(game)
 
 	// render the initial game board
-	if err = views.Game(sess.Game).Render(c.Request.Context(), c.Writer); err != nil {
+	if err = views.Game(sess.Game().Snapshot(), sess.ShowEvaluation()).Render(c.Request.Context(), c.Writer); err != nil {
 		h.handleCriticalErr(c, "Failed to render game board")
 		return
 	}
@@ -69,18 +102,19 @@ func (h *Handlers) CreateGame(c *gin.Context) {
 func (h *Handlers) GetGame(c *gin.Context) {
 	sessionID := c.GetString("session_id")
 	sess, ok := h.sessions.Get(sessionID)
-	if !ok || sess == nil || sess.Game == nil {
+	if !ok || sess == nil || sess.Game() == nil {
 		h.handleCriticalErr(c, "Failed to get active game")
 		return
 	}
 	sess.CloseStream()
-	render(c, views.Game(sess.Game))
+	c.Request = c.Request.WithContext(views.WithViewer(c.Request.Context(), sess.PlayerID()))
+	render(c, views.Game(sess.Game().Snapshot(), sess.ShowEvaluation()))
 }
 
 func (h *Handlers) StreamGame(c *gin.Context) {
 	sessionID := c.GetString("session_id")
 	sess, ok := h.sessions.Get(sessionID)
-	if !ok || sess == nil || sess.Game == nil {
+	if !ok || sess == nil || sess.Game() == nil {
 		h.handleCriticalErr(c, "Failed to get active game")
 		return
 	}
@@ -90,68 +124,120 @@ func (h *Handlers) StreamGame(c *gin.Context) {
 func (h *Handlers) RestartGame(c *gin.Context) {
 	sessionID := c.GetString("session_id")
 	sess, ok := h.sessions.Get(sessionID)
-	if !ok || sess == nil || sess.Game == nil {
+	if !ok || sess == nil || sess.Game() == nil {
 		h.handleCriticalErr(c, "Failed to get active game")
 		return
 	}
-	sess.Game.Restart()
-	sess.Refresh()
+	h.bots.Cancel(sess.ID)
+	var hasHuman bool
+	_ = sess.Game().Update(func(game *connectfour.Game) error {
+		game.Restart()
+		hasHuman = game.HasHuman()
+		return nil
+	})
+	refresh(sess)
+	if hasHuman {
+		h.scheduleBots(c, sess)
+	}
 }
 
 func (h *Handlers) MakeMove(c *gin.Context) {
 	sessionID := c.GetString("session_id")
 	sess, ok := h.sessions.Get(sessionID)
-	if !ok || sess == nil || sess.Game == nil {
-		h.handleCriticalErr(c, "Failed to get active game")
-		return
-	}
-
-	game := sess.Game
-	game.Resume() // if the game was paused, playing a move should automatically resume game
-
-	// the main game loop
-	humanMoved := false
-	for game.InProgress() {
-		player := game.CurrentPlayer()
-
-		if _, ok = player.(*connectfour.HumanPlayer); ok {
-			// if human already moved just return because we're expecting more input
-			if humanMoved {
-				return
-			}
-
-			// make a move from the input
-			var req models.MakeMoveRequest
-			if err := c.ShouldBind(&req); err != nil {
-				h.handleError(c, "An unexpected error has occurred")
-				slog.Error("Failed to bind MakeMoveRequest", "error", err)
-				return
-			}
-			if err := h.service.MakeMove(c, player, game, req.Column); err != nil {
-				h.handleError(c, "Invalid move selection")
-				return
-			}
-			humanMoved = true
-		} else if bot, ok := player.(*connectfour.BotPlayer); ok {
-			// add some artificial delay
-			timer := time.NewTimer(300 * time.Millisecond)
-			col := bot.Evaluate(game.Board)
-			if err := h.service.MakeMove(c, player, game, col); err != nil {
-				h.handleError(c, "Invalid move selection")
-				return
-			}
-			<-timer.C
+	if !ok || sess == nil || sess.Game() == nil {
+		h.handleCriticalErr(c, "Failed to get active game")
+		return
+	}
+
+	var moved bool
+	shared := sess.Game()
+	err := shared.Update(func(game *connectfour.Game) error {
+		game.Resume() // if the game was paused, playing a move should automatically resume game
+
+		// make a move from the input if it's a humans turn
+		player, ok := game.CurrentPlayer().(*connectfour.HumanPlayer)
+		if !ok || !game.InProgress() {
+			return nil
+		}
+		if !sess.Controls(player) {
+			return errNotYourTurn
 		}
 
+		var req models.MakeMoveRequest
+		if err := c.ShouldBind(&req); err != nil {
+			slog.Error("Failed to bind MakeMoveRequest", "error", err)
+			return errBadRequest
+		}
+		if err := h.service.MakeMove(c, player, game, req.Column); err != nil {
+			return errInvalidMove
+		}
 		game.NextPlayer()
-		sess.Refresh()
+		moved = true
+		return nil
+	})
+	switch {
+	case errors.Is(err, errNotYourTurn):
+		h.handleError(c, "Waiting for your opponent")
+		return
+	case errors.Is(err, errBadRequest):
+		h.handleError(c, "An unexpected error has occurred")
+		return
+	case errors.Is(err, errInvalidMove):
+		h.handleError(c, "Invalid move selection")
+		return
+	}
+	if moved {
+		h.service.RateGame(c, shared)
+		refresh(sess)
+	}
+	h.scheduleBots(c, sess)
+}
+
+// scheduleBots hands the game to the bot runner, which plays the bots' moves in the background
+// and pushes them to the session's stream.
+func (h *Handlers) scheduleBots(c *gin.Context, sess *sessions.Session) {
+	if err := h.bots.Schedule(c.Request.Context(), sess); err != nil {
+		TooManyRequests(c, metrics.ScopeBotSearch, "The bots are busy, try again in a moment")
+	}
+}
+
+func (h *Handlers) Rematch(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	sess, ok := h.sessions.Get(sessionID)
+	if !ok || sess == nil || sess.Game() == nil {
+		h.handleCriticalErr(c, "Failed to get active game")
+		return
+	}
+
+	// online players have to agree to a rematch, everyone else starts it straight away
+	var hasHuman bool
+	err := sess.Game().Update(func(game *connectfour.Game) error {
+		hasHuman = game.HasHuman()
+		if sess.PlayerID() == "" {
+			return game.Rematch()
+		}
+		player := actingPlayer(sess, game)
+		if game.RematchBy != nil && game.RematchBy != player {
+			return game.AcceptRematch(player)
+		}
+		return game.RequestRematch(player)
+	})
+	if err != nil {
+		h.handleError(c, "Unable to start a rematch")
+		return
+	}
+	refresh(sess)
+
+	// bots only start on their own when there's a human waiting on them
+	if hasHuman {
+		h.scheduleBots(c, sess)
 	}
 }
 
 func (h *Handlers) ConfigureBot(c *gin.Context) {
 	sessionID := c.GetString("session_id")
 	sess, ok := h.sessions.Get(sessionID)
-	if !ok || sess == nil || sess.Game == nil {
+	if !ok || sess == nil || sess.Game() == nil {
 		h.handleCriticalErr(c, "Failed to get active game")
 		return
 	}
@@ -163,31 +249,324 @@ func (h *Handlers) ConfigureBot(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UpdateBotConfig(sess.Game.Players, req); err != nil {
+	// bots can't be reconfigured mid-search, this waits for the current search to finish
+	err := sess.Game().Update(func(game *connectfour.Game) error {
+		return h.service.UpdateBotConfig(game.Players, req)
+	})
+	if err != nil {
 		h.handleError(c, "Failed to set Difficulty")
 		return
 	}
-	render(c, views.SettingsModal(sess.Game))
+	render(c, views.SettingsModal(sess.Game().Snapshot()))
 }
 
 func (h *Handlers) StopGame(c *gin.Context) {
 	sessionID := c.GetString("session_id")
 	sess, ok := h.sessions.Get(sessionID)
-	if !ok || sess == nil || sess.Game == nil {
+	if !ok || sess == nil || sess.Game() == nil {
 		h.handleCriticalErr(c, "Failed to get active game")
 		return
 	}
-	sess.Game.Stop()
+	h.bots.Pause(sess)
+	refresh(sess)
+}
+
+// ResumeGame carries on with a bot game that was paused or hasn't started.
+func (h *Handlers) ResumeGame(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	sess, ok := h.sessions.Get(sessionID)
+	if !ok || sess == nil || sess.Game() == nil {
+		h.handleCriticalErr(c, "Failed to get active game")
+		return
+	}
+	if err := h.bots.Resume(c.Request.Context(), sess); err != nil {
+		TooManyRequests(c, metrics.ScopeBotSearch, "The bots are busy, try again in a moment")
+		return
+	}
+	refresh(sess)
+}
+
+// StepGame plays the next bot move in a paused bot game.
+func (h *Handlers) StepGame(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	sess, ok := h.sessions.Get(sessionID)
+	if !ok || sess == nil || sess.Game() == nil {
+		h.handleCriticalErr(c, "Failed to get active game")
+		return
+	}
+	if err := h.bots.Step(c.Request.Context(), sess); err != nil {
+		TooManyRequests(c, metrics.ScopeBotSearch, "The bots are busy, try again in a moment")
+	}
+}
+
+func (h *Handlers) Resign(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	sess, ok := h.sessions.Get(sessionID)
+	if !ok || sess == nil || sess.Game() == nil {
+		h.handleCriticalErr(c, "Failed to get active game")
+		return
+	}
+
+	shared := sess.Game()
+	err := shared.Update(func(game *connectfour.Game) error {
+		player := actingPlayer(sess, game)
+		if player == nil {
+			return errNoPlayer
+		}
+		return h.service.Resign(c.Request.Context(), game, player)
+	})
+	if errors.Is(err, errNoPlayer) {
+		h.handleError(c, "There's nobody to resign for")
+		return
+	} else if err != nil {
+		h.handleError(c, "Unable to resign")
+		return
+	}
+	h.service.RateGame(c.Request.Context(), shared)
+	refresh(sess)
+}
+
+func (h *Handlers) OfferDraw(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	sess, ok := h.sessions.Get(sessionID)
+	if !ok || sess == nil || sess.Game() == nil {
+		h.handleCriticalErr(c, "Failed to get active game")
+		return
+	}
+
+	var declinedByBot bool
+	shared := sess.Game()
+	err := shared.Update(func(game *connectfour.Game) error {
+		player := actingPlayer(sess, game)
+		if player == nil {
+			return errNoPlayer
+		}
+		drawn, err := h.service.OfferDraw(c.Request.Context(), game, player)
+		if err != nil {
+			return err
+		}
+		_, isBot := game.Opponent(player).(*connectfour.BotPlayer)
+		declinedByBot = isBot && !drawn
+		return nil
+	})
+	if errors.Is(err, errNoPlayer) {
+		h.handleError(c, "There's nobody to offer a draw for")
+		return
+	} else if err != nil {
+		h.handleError(c, "Unable to offer a draw")
+		return
+	}
+	if declinedByBot {
+		render(c, views.WarningToast("The bot declined your draw offer"))
+	}
+	h.service.RateGame(c.Request.Context(), shared)
+	refresh(sess)
+}
+
+func (h *Handlers) AcceptDraw(c *gin.Context) {
+	h.answerDraw(c, "Unable to accept the draw", func(game *connectfour.Game, player connectfour.Player) error {
+		return h.service.AcceptDraw(c.Request.Context(), game, player)
+	})
+}
+
+func (h *Handlers) DeclineDraw(c *gin.Context) {
+	h.answerDraw(c, "Unable to decline the draw", func(game *connectfour.Game, player connectfour.Player) error {
+		return game.DeclineDraw(player)
+	})
+}
+
+// answerDraw answers a draw offer for the player it was made to, only they can answer it.
+func (h *Handlers) answerDraw(c *gin.Context, failure string, answer func(game *connectfour.Game, player connectfour.Player) error) {
+	sessionID := c.GetString("session_id")
+	sess, ok := h.sessions.Get(sessionID)
+	if !ok || sess == nil || sess.Game() == nil {
+		h.handleCriticalErr(c, "Failed to get active game")
+		return
+	}
+
+	shared := sess.Game()
+	err := shared.Update(func(game *connectfour.Game) error {
+		if game.DrawOfferedBy == nil || !sess.Controls(game.Opponent(game.DrawOfferedBy)) {
+			return errNoDrawOffer
+		}
+		return answer(game, game.Opponent(game.DrawOfferedBy))
+	})
+	if errors.Is(err, errNoDrawOffer) {
+		h.handleError(c, "There's no draw offer to answer")
+		return
+	} else if err != nil {
+		h.handleError(c, failure)
+		return
+	}
+	h.service.RateGame(c.Request.Context(), shared)
+	refresh(sess)
 }
 
 func (h *Handlers) Settings(c *gin.Context) {
 	sessionID := c.GetString("session_id")
 	sess, ok := h.sessions.Get(sessionID)
-	if !ok || sess == nil || sess.Game == nil {
+	if !ok || sess == nil || sess.Game() == nil {
 		h.handleCriticalErr(c, "Failed to get active game")
 		return
 	}
-	render(c, views.SettingsModal(sess.Game))
+	render(c, views.SettingsModal(sess.Game().Snapshot()))
+}
+
+// GetEvaluation renders the evaluation panel for the current position.
+func (h *Handlers) GetEvaluation(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	sess, ok := h.sessions.Get(sessionID)
+	if !ok || sess == nil || sess.Game() == nil {
+		h.handleCriticalErr(c, "Failed to get active game")
+		return
+	}
+	render(c, evaluationPanel(sess))
+}
+
+// ToggleEvaluation shows or hides the evaluation bar, it isn't available in online games.
+func (h *Handlers) ToggleEvaluation(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	sess, ok := h.sessions.Get(sessionID)
+	if !ok || sess == nil || sess.Game() == nil {
+		h.handleCriticalErr(c, "Failed to get active game")
+		return
+	}
+	if sess.PlayerID() != "" {
+		h.handleError(c, "The evaluation isn't available in online games")
+		return
+	}
+	sess.SetShowEvaluation(!sess.ShowEvaluation())
+	render(c, evaluationPanel(sess))
+}
+
+func evaluationPanel(sess *sessions.Session) templ.Component {
+	if !sess.ShowEvaluation() {
+		return views.EvaluationPanel(nil)
+	}
+	eval := models.NewEvaluation(sess.Game().Snapshot())
+	return views.EvaluationPanel(&eval)
+}
+
+// PreviewMove renders the position after one of the game's moves, without touching the game.
+func (h *Handlers) PreviewMove(c *gin.Context) {
+	var req models.MovePreviewRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		slog.Error("Failed to bind MovePreviewRequest", "error", err)
+		h.handleError(c, "An unexpected error has occurred")
+		return
+	}
+
+	sessionID := c.GetString("session_id")
+	sess, ok := h.sessions.Get(sessionID)
+	if !ok || sess == nil || sess.Game() == nil {
+		h.handleCriticalErr(c, "Failed to get active game")
+		return
+	}
+	game := sess.Game().Snapshot()
+	if req.Ply < 1 || req.Ply > len(game.Moves) {
+		h.handleError(c, "That move hasn't been played")
+		return
+	}
+
+	preview, err := connectfour.Replay(game.Setup(), game.Moves[:req.Ply])
+	if err != nil {
+		slog.Error("Failed to replay game", "game_id", game.ID, "ply", req.Ply, "error", err)
+		h.handleError(c, "Failed to preview the move")
+		return
+	}
+	render(c, views.MovePreview(preview, req.Ply, len(game.Moves)))
+}
+
+func (h *Handlers) ReplayGame(c *gin.Context) {
+	var req models.ReplayRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		slog.Error("Failed to bind ReplayRequest", "error", err)
+		h.handleError(c, "An unexpected error has occurred")
+		return
+	}
+
+	replay, err := h.service.LoadReplay(c.Request.Context(), c.Param("id"), req)
+	if errors.Is(err, repository.ErrGameNotFound) {
+		h.handleCriticalErr(c, "Game not found")
+		return
+	} else if err != nil {
+		slog.Error("Failed to load replay", "game_id", c.Param("id"), "error", err)
+		h.handleCriticalErr(c, "Failed to load replay")
+		return
+	}
+
+	// step controls only swap the board, everything else gets the full page
+	if c.GetHeader("HX-Target") == "replay-container" {
+		render(c, views.ReplayBoard(replay))
+		return
+	}
+	c.Request = c.Request.WithContext(views.WithBaseURL(c.Request.Context(), h.publicURL))
+	render(c, views.Replay(replay))
+}
+
+func (h *Handlers) Leaderboard(c *gin.Context) {
+	var req models.LeaderboardRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		slog.Error("Failed to bind LeaderboardRequest", "error", err)
+		h.handleError(c, "An unexpected error has occurred")
+		return
+	}
+
+	board, err := h.service.Leaderboard(c.Request.Context(), req)
+	if err != nil {
+		slog.Error("Failed to load leaderboard", "error", err)
+		h.handleCriticalErr(c, "Failed to load leaderboard")
+		return
+	}
+
+	// switching views only swaps the table
+	if c.GetHeader("HX-Target") == "leaderboard-container" {
+		render(c, views.LeaderboardTable(board))
+		return
+	}
+	render(c, views.Leaderboard(board))
+}
+
+func (h *Handlers) LeaderboardJSON(c *gin.Context) {
+	var req models.LeaderboardRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	board, err := h.service.Leaderboard(c.Request.Context(), req)
+	if err != nil {
+		slog.Error("Failed to load leaderboard", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load leaderboard"})
+		return
+	}
+	c.JSON(http.StatusOK, board)
+}
+
+// actingPlayer is the human the session acts for: their own player in an online game, otherwise
+// the human whose turn it is, or the only human when playing a bot.
+func actingPlayer(sess *sessions.Session, game *connectfour.Game) connectfour.Player {
+	if sess.PlayerID() != "" {
+		player, _ := game.FindPlayer(sess.PlayerID())
+		return player
+	}
+	if _, ok := game.CurrentPlayer().(*connectfour.HumanPlayer); ok {
+		return game.CurrentPlayer()
+	}
+	for _, player := range game.Players {
+		if _, ok := player.(*connectfour.HumanPlayer); ok {
+			return player
+		}
+	}
+	return nil
+}
+
+// refresh re-renders the game for the session, and their opponent in an online game.
+func refresh(sess *sessions.Session) {
+	sess.Refresh()
+	if sess.Opponent() != nil {
+		sess.Opponent().Refresh()
+	}
 }
 
 func render(c *gin.Context, component templ.Component) {
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/repository"
+	"github.com/Zach51920/connect-four/internal/services"
+	"github.com/Zach51920/connect-four/internal/sessions"
+	"github.com/Zach51920/connect-four/internal/sqlite"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandlers_PreviewMove(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := sessions.NewMemorySessionStore()
+	defer store.Close()
+	handle := New(store, nil, nil, nil, nil)
+
+	game := connectfour.NewGame(connectfour.NewHumanPlayerPair())
+	for _, col := range []int{3, 3, 2} {
+		if _, err := game.Play(game.CurrentPlayer(), col); err != nil {
+			t.Fatalf("failed to play column %d: %v", col, err)
+		}
+		game.NextPlayer()
+	}
+	store.New("preview", game)
+
+	tests := []struct {
+		name string
+		ply  int
+		want string
+	}{
+		{"before the first move", 0, "That move hasn&#39;t been played"},
+		{"first move", 1, "After move 1 of 3"},
+		{"last move", 3, "After move 3 of 3"},
+		{"after the last move", 4, "That move hasn&#39;t been played"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest("GET", fmt.Sprintf("/game/history/preview?ply=%d", tt.ply), nil)
+			c.Set("session_id", "preview")
+
+			handle.PreviewMove(c)
+			if body := recorder.Body.String(); !strings.Contains(body, tt.want) {
+				t.Errorf("expected the response to contain %q, got %s", tt.want, body)
+			}
+		})
+	}
+}
+
+func TestHandlers_ReplayGame_PublicURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider, err := sqlite.NewProvider(&sqlite.Config{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Close()
+	repo := repository.NewSQLiteRepository(provider.DB())
+	store := sessions.NewMemorySessionStore()
+	defer store.Close()
+	handle := New(store, services.NewGameService(repo), nil, nil, nil)
+	handle.SetPublicURL("https://connect-four.example.com")
+
+	game := connectfour.NewGame(connectfour.NewHumanPlayerPair())
+	player := game.CurrentPlayer()
+	if _, err = game.Play(player, 3); err != nil {
+		t.Fatalf("failed to play: %v", err)
+	}
+	if err = repo.SaveMove(context.Background(), game, player, 3); err != nil {
+		t.Fatalf("failed to save move: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest("GET", "/games/"+game.ID+"/replay", nil)
+	c.Request.Host = "attacker.example"
+	c.Request.Header.Set("X-Forwarded-Proto", "javascript")
+	c.Params = gin.Params{{Key: "id", Value: game.ID}}
+
+	handle.ReplayGame(c)
+	body := recorder.Body.String()
+	want := fmt.Sprintf(`content="https://connect-four.example.com/games/%s/replay?ply=1"`, game.ID)
+	if !strings.Contains(body, want) {
+		t.Errorf("expected the page to link to the public URL %s, got %s", want, body)
+	}
+	if strings.Contains(body, "attacker.example") || strings.Contains(body, "javascript:") {
+		t.Errorf("expected the request's host and proto to be ignored, got %s", body)
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"path"
+
+	"github.com/Zach51920/connect-four/internal/boardimage"
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/models"
+	"github.com/Zach51920/connect-four/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// GameImage draws a stored game as an SVG or PNG, the format comes from the path's extension.
+// The ply query parameter picks the position, it defaults to the end of the game.
+func (h *Handlers) GameImage(c *gin.Context) {
+	var req models.ImageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.String(http.StatusBadRequest, "invalid image request")
+		return
+	}
+
+	replay, err := h.service.LoadReplay(c.Request.Context(), c.Param("id"), models.ReplayRequest{Ply: req.Ply})
+	if errors.Is(err, repository.ErrGameNotFound) {
+		c.String(http.StatusNotFound, "game not found")
+		return
+	} else if err != nil {
+		slog.Error("Failed to load game image", "game_id", c.Param("id"), "error", err)
+		c.String(http.StatusInternalServerError, "failed to load game")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age="+imageMaxAge(!replay.Game.InProgress()))
+	writeImage(c, replay.Game.Board, req)
+}
+
+// ReplayGIF animates a stored game from the first move to the last.
+func (h *Handlers) ReplayGIF(c *gin.Context) {
+	var req models.ReplayGIFRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.String(http.StatusBadRequest, "invalid replay request")
+		return
+	}
+
+	data, finished, err := h.service.ReplayGIF(c.Request.Context(), c.Param("id"), req)
+	if errors.Is(err, repository.ErrGameNotFound) {
+		c.String(http.StatusNotFound, "game not found")
+		return
+	} else if err != nil {
+		slog.Error("Failed to render replay GIF", "game_id", c.Param("id"), "error", err)
+		c.String(http.StatusInternalServerError, "failed to render replay")
+		return
+	}
+	c.Header("Cache-Control", "public, max-age="+imageMaxAge(finished))
+	c.Data(http.StatusOK, "image/gif", data)
+}
+
+// PositionImage draws the position a move string leads to, like /position/image.png?moves=ddcef.
+func (h *Handlers) PositionImage(c *gin.Context) {
+	var req models.ImageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.String(http.StatusBadRequest, "invalid image request")
+		return
+	}
+	board, err := h.service.Position(req.Moves)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	writeImage(c, board, req)
+}
+
+// imageMaxAge is how long a game's image can be cached for, images of finished games never
+// change while ongoing games get new moves.
+func imageMaxAge(finished bool) string {
+	if finished {
+		return "86400"
+	}
+	return "60"
+}
+
+func writeImage(c *gin.Context, board *connectfour.Board, req models.ImageRequest) {
+	theme := boardimage.ParseTheme(req.Theme)
+	opts := boardimage.Options{Theme: &theme, Coordinates: req.Coordinates, Highlight: true, LastMove: true}
+
+	var buf bytes.Buffer
+	var err error
+	contentType := "image/png"
+	if path.Ext(c.Request.URL.Path) == ".svg" {
+		contentType = "image/svg+xml"
+		err = boardimage.SVG(&buf, board, opts)
+	} else {
+		err = boardimage.PNG(&buf, board, opts)
+	}
+	if err != nil {
+		slog.Error("Failed to draw board", "error", err)
+		c.String(http.StatusInternalServerError, "failed to draw board")
+		return
+	}
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/Zach51920/connect-four/internal/matchmaking"
+	"github.com/Zach51920/connect-four/internal/models"
+	"github.com/Zach51920/connect-four/internal/services"
+	"github.com/Zach51920/connect-four/internal/views"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+)
+
+func (h *Handlers) Matchmaking(c *gin.Context) {
+	render(c, views.Matchmaking())
+}
+
+func (h *Handlers) JoinQueue(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	sess, ok := h.sessions.Get(sessionID)
+	if !ok || sess == nil {
+		sess = h.sessions.New(sessionID, nil)
+	}
+
+	var req models.MatchmakingRequest
+	if err := c.ShouldBind(&req); err != nil {
+		slog.Error("Failed to bind MatchmakingRequest", "error", err)
+		h.handleError(c, "An unexpected error has occurred")
+		return
+	}
+
+	// abandon any game in progress, the queue takes over the session's stream
+	if sess.Game() != nil {
+		h.abort(sess)
+	}
+	sess.CloseStream()
+	sess.SetGame(nil)
+
+	status, err := h.matchmaking.Join(c.Request.Context(), sess, h.currentUser(c), req)
+	switch {
+	case errors.Is(err, services.ErrInvalidTimeControl):
+		h.handleError(c, "Unknown time control")
+		return
+	case errors.Is(err, matchmaking.ErrAlreadyQueued):
+		h.handleError(c, "You're already searching for a game")
+		return
+	case errors.Is(err, matchmaking.ErrUserQueued):
+		h.handleError(c, "You're already searching for a game in another window")
+		return
+	case err != nil:
+		slog.Error("Failed to join matchmaking queue", "error", err)
+		h.handleError(c, "Failed to join the queue")
+		return
+	}
+	render(c, views.Searching(status))
+}
+
+func (h *Handlers) StreamQueue(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	sess, ok := h.sessions.Get(sessionID)
+	if !ok || sess == nil {
+		h.handleCriticalErr(c, "Failed to get active session")
+		return
+	}
+	sess.Stream(c)
+}
+
+func (h *Handlers) LeaveQueue(c *gin.Context) {
+	h.matchmaking.Leave(c.GetString("session_id"))
+	h.Home(c)
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Zach51920/connect-four/internal/metrics"
+	"github.com/Zach51920/connect-four/internal/views"
+	"github.com/gin-gonic/gin"
+)
+
+// TooManyRequests turns a request away with a 429. htmx requests get a toast added to the page,
+// anything else gets a JSON error.
+func TooManyRequests(c *gin.Context, scope, message string) {
+	metrics.RateLimited.WithLabelValues(scope).Inc()
+	c.Header("Retry-After", "1")
+	if c.GetHeader("HX-Request") == "" {
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": message})
+		return
+	}
+
+	// htmx is configured to swap 429s, the toast is added to the page wherever it was targeted
+	c.Header("HX-Retarget", "body")
+	c.Header("HX-Reswap", "beforeend")
+	c.Status(http.StatusTooManyRequests)
+	render(c, views.WarningToast(message))
+	c.Abort()
+}
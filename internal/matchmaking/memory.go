@@ -0,0 +1,167 @@
+package matchmaking
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryQueue is a Queue for a single server instance.
+type MemoryQueue struct {
+	config Config
+	now    func() time.Time
+
+	ticketMu sync.Mutex
+	tickets  map[string]Ticket
+
+	events       chan Event
+	shutdownOnce sync.Once
+	shutdownCh   chan struct{}
+}
+
+func NewMemoryQueue(config Config) *MemoryQueue {
+	q := newMemoryQueue(config)
+	go q.start()
+	return q
+}
+
+func newMemoryQueue(config Config) *MemoryQueue {
+	return &MemoryQueue{
+		config:     config,
+		now:        time.Now,
+		tickets:    make(map[string]Ticket),
+		events:     make(chan Event, 64),
+		shutdownCh: make(chan struct{}),
+	}
+}
+
+func (q *MemoryQueue) Join(ticket Ticket) error {
+	q.ticketMu.Lock()
+	defer q.ticketMu.Unlock()
+	if _, ok := q.tickets[ticket.SessionID]; ok {
+		return ErrAlreadyQueued
+	}
+	if ticket.UserID != "" {
+		for _, other := range q.tickets {
+			if other.UserID == ticket.UserID {
+				return ErrUserQueued
+			}
+		}
+	}
+	ticket.JoinedAt = q.now()
+	q.tickets[ticket.SessionID] = ticket
+	return nil
+}
+
+func (q *MemoryQueue) Leave(sessionID string) bool {
+	q.ticketMu.Lock()
+	defer q.ticketMu.Unlock()
+	_, ok := q.tickets[sessionID]
+	delete(q.tickets, sessionID)
+	return ok
+}
+
+func (q *MemoryQueue) Events() <-chan Event {
+	return q.events
+}
+
+func (q *MemoryQueue) Close() {
+	q.shutdownOnce.Do(func() {
+		close(q.shutdownCh)
+	})
+}
+
+func (q *MemoryQueue) start() {
+	ticker := time.NewTicker(q.config.TickInterval)
+	defer ticker.Stop()
+	defer close(q.events)
+
+	for {
+		select {
+		case <-q.shutdownCh:
+			return
+		case <-ticker.C:
+			for _, event := range q.tick() {
+				select {
+				case q.events <- event:
+				case <-q.shutdownCh:
+					return
+				}
+			}
+		}
+	}
+}
+
+// tick pairs up every ticket it can, oldest first, and times out tickets that have waited too long.
+func (q *MemoryQueue) tick() []Event {
+	q.ticketMu.Lock()
+	defer q.ticketMu.Unlock()
+
+	now := q.now()
+	tickets := make([]Ticket, 0, len(q.tickets))
+	for _, ticket := range q.tickets {
+		tickets = append(tickets, ticket)
+	}
+	sort.Slice(tickets, func(i, j int) bool { return tickets[i].JoinedAt.Before(tickets[j].JoinedAt) })
+
+	var events []Event
+	matched := make(map[string]bool)
+	for i, ticket := range tickets {
+		if matched[ticket.SessionID] {
+			continue
+		}
+		band := q.config.Band(now.Sub(ticket.JoinedAt))
+
+		// find the closest rated opponent that both players would accept
+		best, bestDiff := -1, math.Inf(1)
+		for j := i + 1; j < len(tickets); j++ {
+			other := tickets[j]
+			if matched[other.SessionID] || other.TimeControl != ticket.TimeControl || sameUser(ticket, other) {
+				continue
+			}
+			diff := math.Abs(ticket.Rating - other.Rating)
+			if diff <= band && diff <= q.config.Band(now.Sub(other.JoinedAt)) && diff < bestDiff {
+				best, bestDiff = j, diff
+			}
+		}
+		if best == -1 {
+			continue
+		}
+
+		other := tickets[best]
+		matched[ticket.SessionID], matched[other.SessionID] = true, true
+		delete(q.tickets, ticket.SessionID)
+		delete(q.tickets, other.SessionID)
+		events = append(events, Event{Type: EventMatched, Tickets: []Ticket{ticket, other}})
+	}
+
+	queued := make(map[string]int)
+	for _, ticket := range q.tickets {
+		queued[ticket.TimeControl]++
+	}
+	for _, ticket := range tickets {
+		if matched[ticket.SessionID] {
+			continue
+		}
+		waiting := now.Sub(ticket.JoinedAt)
+		if waiting >= q.config.BotTimeout {
+			delete(q.tickets, ticket.SessionID)
+			events = append(events, Event{Type: EventTimedOut, Tickets: []Ticket{ticket}, Waiting: waiting})
+			continue
+		}
+		events = append(events, Event{
+			Type:    EventWaiting,
+			Tickets: []Ticket{ticket},
+			Waiting: waiting,
+			Band:    q.config.Band(waiting),
+			Queued:  queued[ticket.TimeControl],
+		})
+	}
+	return events
+}
+
+// sameUser reports whether both tickets belong to the same account, guests never do.
+func sameUser(a, b Ticket) bool {
+	return a.UserID != "" && a.UserID == b.UserID
+}
@@ -0,0 +1,94 @@
+package matchmaking
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryQueue_Tick(t *testing.T) {
+	now := time.Now()
+	q := newMemoryQueue(DefaultConfig())
+	q.now = func() time.Time { return now }
+
+	join := func(id string, rating float64, timeControl string) {
+		if err := q.Join(Ticket{SessionID: id, Rating: rating, TimeControl: timeControl}); err != nil {
+			t.Fatalf("failed to join %s: %v", id, err)
+		}
+	}
+	matches := func(events []Event) [][2]string {
+		var pairs [][2]string
+		for _, event := range events {
+			if event.Type == EventMatched {
+				pairs = append(pairs, [2]string{event.Tickets[0].SessionID, event.Tickets[1].SessionID})
+			}
+		}
+		return pairs
+	}
+
+	join("a", 1500, TimeControlRapid)
+	join("b", 1800, TimeControlRapid)
+	join("c", 1550, TimeControlBlitz)
+	if err := q.Join(Ticket{SessionID: "a"}); err != ErrAlreadyQueued {
+		t.Errorf("expected ErrAlreadyQueued, got %v", err)
+	}
+
+	// nobody is close enough in rating with a matching time control
+	if pairs := matches(q.tick()); len(pairs) != 0 {
+		t.Fatalf("expected no matches, got %v", pairs)
+	}
+
+	// the band widens enough for a and b to be paired after 20 seconds
+	now = now.Add(20 * time.Second)
+	pairs := matches(q.tick())
+	if len(pairs) != 1 || pairs[0] != [2]string{"a", "b"} {
+		t.Fatalf("expected a and b to be matched, got %v", pairs)
+	}
+
+	// c is left alone until it times out
+	now = now.Add(DefaultConfig().BotTimeout)
+	events := q.tick()
+	if len(events) != 1 || events[0].Type != EventTimedOut || events[0].Tickets[0].SessionID != "c" {
+		t.Fatalf("expected c to time out, got %+v", events)
+	}
+	if q.Leave("c") {
+		t.Errorf("expected c to have left the queue")
+	}
+}
+
+func TestMemoryQueue_SameUser(t *testing.T) {
+	now := time.Now()
+	q := newMemoryQueue(DefaultConfig())
+	q.now = func() time.Time { return now }
+
+	if err := q.Join(Ticket{SessionID: "a", UserID: "u1", Rating: 1500, TimeControl: TimeControlRapid}); err != nil {
+		t.Fatalf("failed to join a: %v", err)
+	}
+	if err := q.Join(Ticket{SessionID: "b", UserID: "u1", Rating: 1500, TimeControl: TimeControlRapid}); err != ErrUserQueued {
+		t.Errorf("expected ErrUserQueued, got %v", err)
+	}
+
+	// tickets from the same user that got in anyway, like one put back after a failed match,
+	// are never paired
+	q.tickets["b"] = Ticket{SessionID: "b", UserID: "u1", Rating: 1500, TimeControl: TimeControlRapid, JoinedAt: now}
+	for _, event := range q.tick() {
+		if event.Type == EventMatched {
+			t.Fatalf("expected the user not to be matched against themselves, got %+v", event.Tickets)
+		}
+	}
+
+	// guests don't have a user ID to compare, they're matched as usual
+	for _, id := range []string{"c", "d"} {
+		if err := q.Join(Ticket{SessionID: id, Rating: 1200, TimeControl: TimeControlBlitz}); err != nil {
+			t.Fatalf("failed to join %s: %v", id, err)
+		}
+	}
+	var matched int
+	for _, event := range q.tick() {
+		if event.Type == EventMatched {
+			matched++
+		}
+	}
+	if matched != 1 {
+		t.Errorf("expected the guests to be matched, got %d matches", matched)
+	}
+}
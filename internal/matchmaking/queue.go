@@ -0,0 +1,85 @@
+package matchmaking
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	TimeControlCasual = "casual"
+	TimeControlRapid  = "rapid"
+	TimeControlBlitz  = "blitz"
+)
+
+var TimeControls = []string{TimeControlCasual, TimeControlRapid, TimeControlBlitz}
+
+var (
+	ErrAlreadyQueued = errors.New("session is already queued")
+	// ErrUserQueued means the user is already queued from another session, a user is never
+	// matched against themselves.
+	ErrUserQueued = errors.New("user is already queued in another session")
+)
+
+// Ticket is a sessions place in the queue.
+type Ticket struct {
+	SessionID   string
+	UserID      string
+	Name        string
+	Rating      float64
+	TimeControl string
+	JoinedAt    time.Time
+}
+
+type EventType int
+
+const (
+	// EventWaiting reports the progress of a ticket that is still in the queue.
+	EventWaiting EventType = iota
+	// EventMatched pairs two tickets, both have left the queue.
+	EventMatched
+	// EventTimedOut means no opponent was found in time, the ticket has left the queue.
+	EventTimedOut
+)
+
+type Event struct {
+	Type    EventType
+	Tickets []Ticket
+	Waiting time.Duration
+	Band    float64 // rating difference the ticket currently accepts
+	Queued  int     // tickets waiting for the same time control
+}
+
+// Queue pairs sessions looking for an online game. Implementations push every change to the
+// Events channel, which must be drained by the caller.
+type Queue interface {
+	Join(ticket Ticket) error
+	Leave(sessionID string) bool
+	Events() <-chan Event
+	Close()
+}
+
+type Config struct {
+	// InitialBand is the rating difference a ticket accepts as soon as it joins.
+	InitialBand float64
+	// BandGrowth is how much the band widens for every second spent waiting.
+	BandGrowth float64
+	MaxBand    float64
+	// BotTimeout is how long a ticket waits before giving up and offering a bot instead.
+	BotTimeout   time.Duration
+	TickInterval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		InitialBand:  100,
+		BandGrowth:   10,
+		MaxBand:      600,
+		BotTimeout:   60 * time.Second,
+		TickInterval: time.Second,
+	}
+}
+
+// Band returns the rating difference accepted after waiting for the given time.
+func (c Config) Band(waiting time.Duration) float64 {
+	return min(c.InitialBand+c.BandGrowth*waiting.Seconds(), c.MaxBand)
+}
@@ -0,0 +1,107 @@
+// Package metrics holds the Prometheus metrics exported on /metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "connectfour"
+
+var (
+	GamesCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "games_created_total",
+		Help:      "Games created, by game type.",
+	}, []string{"type"})
+
+	GamesFinished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "games_finished_total",
+		Help:      "Games finished, by how they were decided.",
+	}, []string{"result"})
+
+	MovesPerGame = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "moves_per_game",
+		Help:      "Number of moves played in finished games.",
+		Buckets:   prometheus.LinearBuckets(7, 7, 6),
+	})
+
+	BotThinkTime = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "bot_think_seconds",
+		Help:      "Time bots take to choose a move, by strategy and difficulty.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	}, []string{"strategy", "difficulty"})
+
+	NodesSearched = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "minimax_nodes_searched",
+		Help:      "Positions the minimax strategy searched to choose a move, by difficulty.",
+		Buckets:   prometheus.ExponentialBuckets(10, 4, 10),
+	}, []string{"difficulty"})
+
+	ActiveSessions = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_sessions",
+		Help:      "Game sessions held in memory.",
+	})
+
+	ActiveStreams = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_streams",
+		Help:      "Open SSE and websocket streams.",
+	})
+
+	RepositorySaveDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_save_seconds",
+		Help:      "Time taken by repository writes, by storage backend and operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"backend", "operation"})
+
+	RepositorySaveErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "repository_save_errors_total",
+		Help:      "Failed repository writes, by storage backend and operation.",
+	}, []string{"backend", "operation"})
+
+	RateLimits = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rate_limit",
+		Help:      "Configured limits, by scope (session, ip or bot_search) and setting.",
+	}, []string{"scope", "setting"})
+
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests turned away with a 429, by the limit they hit.",
+	}, []string{"scope"})
+
+	BotSearchesActive = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "bot_searches_active",
+		Help:      "Bot searches running for web requests.",
+	})
+
+	BotSearchesQueued = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "bot_searches_queued",
+		Help:      "Bot searches waiting for a free slot.",
+	})
+)
+
+const (
+	ScopeSession   = "session"
+	ScopeIP        = "ip"
+	ScopeBotSearch = "bot_search"
+)
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
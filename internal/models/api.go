@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+// messages sent by API clients over the game websocket
+const (
+	MessageJoin   = "join"
+	MessageMove   = "move"
+	MessageResign = "resign"
+	MessageLeave  = "leave"
+)
+
+// messages sent by the server, along with the session events
+const (
+	MessageState = "state"
+	MessageError = "error"
+)
+
+// APIRequest is a message from an API client, only the fields for its type are set.
+type APIRequest struct {
+	Type        string `json:"type"`
+	TimeControl string `json:"time_control,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Column      int    `json:"column,omitempty"`
+}
+
+// APIMessage is a message to an API client, Data depends on the type.
+type APIMessage struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+func NewAPIMessage(messageType string, data any) (APIMessage, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return APIMessage{}, err
+	}
+	return APIMessage{Type: messageType, Data: raw}, nil
+}
+
+type APIError struct {
+	Message string `json:"message"`
+}
+
+var gameStates = map[connectfour.GameState]string{
+	connectfour.GameStateNew:       "new",
+	connectfour.GameStateOngoing:   "ongoing",
+	connectfour.GameStateWin:       "win",
+	connectfour.GameStateDraw:      "draw",
+	connectfour.GameStateStopped:   "stopped",
+	connectfour.GameStateCancelled: "cancelled",
+}
+
+// GameState is the game as API clients see it.
+type GameState struct {
+	ID       string         `json:"id"`
+	Board    []string       `json:"board"` // top row first, "." for an empty cell
+	Players  [2]PlayerState `json:"players"`
+	Turn     string         `json:"turn"` // ID of the player to move
+	State    string         `json:"state"`
+	WinnerID string         `json:"winner_id,omitempty"`
+	Result   string         `json:"result,omitempty"`
+	Moves    string         `json:"moves"`
+}
+
+func (s GameState) InProgress() bool {
+	return s.State == gameStates[connectfour.GameStateNew] || s.State == gameStates[connectfour.GameStateOngoing]
+}
+
+func (s GameState) Drawn() bool {
+	return s.State == gameStates[connectfour.GameStateDraw]
+}
+
+type PlayerState struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Token string `json:"token"`
+	Score uint64 `json:"score"`
+}
+
+func NewGameState(game *connectfour.Game) GameState {
+	state := GameState{
+		ID:     game.ID,
+		Board:  make([]string, len(game.Board.Cells)),
+		Turn:   game.CurrentPlayer().ID(),
+		State:  gameStates[game.State],
+		Result: string(game.Result),
+		Moves:  connectfour.FormatMoves(game.Moves),
+	}
+	for i, row := range game.Board.Cells {
+		var b strings.Builder
+		for _, cell := range row {
+			if cell == 0 {
+				cell = '.'
+			}
+			b.WriteRune(cell)
+		}
+		state.Board[i] = b.String()
+	}
+	for i, player := range game.Players {
+		state.Players[i] = PlayerState{ID: player.ID(), Name: player.Name(), Token: string(player.Token()), Score: player.Score()}
+	}
+	if game.Winner != nil {
+		state.WinnerID = game.Winner.ID()
+	}
+	return state
+}
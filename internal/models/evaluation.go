@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+const (
+	// EvaluationDepth is how far ahead the evaluation bar searches.
+	EvaluationDepth = 6
+	// evaluationScale is the score where the bar is three quarters full, heuristic scores rarely
+	// get far past it before the search finds a win.
+	evaluationScale = 40
+)
+
+// Evaluation is the engine's view of a game for the evaluation bar. It's from the first player's
+// point of view, like the bar.
+type Evaluation struct {
+	Players  [2]string
+	Tokens   [2]rune
+	Score    float64
+	Finished bool
+	// PV is the line of play the engine expects, in move notation.
+	PV []string
+}
+
+func NewEvaluation(game *connectfour.Game) Evaluation {
+	analysis := game.Analyze(EvaluationDepth)
+	eval := Evaluation{Score: analysis.Score, Finished: !game.InProgress() && game.Result != ""}
+	for i, player := range game.Players {
+		eval.Players[i], eval.Tokens[i] = player.Name(), player.Token()
+	}
+	for _, col := range analysis.PV {
+		eval.PV = append(eval.PV, connectfour.ColumnName(col))
+	}
+	return eval
+}
+
+// Advantage is how much of the bar is the first player's, from 0 to 100. Only decided games
+// fill the bar completely.
+func (e Evaluation) Advantage() int {
+	switch {
+	case e.Score >= connectfour.WinScore:
+		return 100
+	case e.Score <= -connectfour.WinScore:
+		return 0
+	}
+	share := 50 + 50*math.Tanh(e.Score*math.Atanh(0.5)/evaluationScale)
+	return int(math.Round(min(max(share, 5), 95)))
+}
+
+// Summary says who is better in words.
+func (e Evaluation) Summary() string {
+	leader := e.Players[0]
+	if e.Score < 0 {
+		leader = e.Players[1]
+	}
+	switch score := math.Abs(e.Score); {
+	case score >= connectfour.WinScore && e.Finished:
+		return fmt.Sprintf("%s won", leader)
+	case score >= connectfour.WinScore:
+		return fmt.Sprintf("%s has a winning line", leader)
+	case e.Finished:
+		return "Draw"
+	case score == 0:
+		return "Even"
+	case score < evaluationScale/4:
+		return fmt.Sprintf("%s is slightly better", leader)
+	default:
+		return fmt.Sprintf("%s is better", leader)
+	}
+}
+
+// ScoreText is the score as shown on the bar.
+func (e Evaluation) ScoreText() string {
+	if math.Abs(e.Score) >= connectfour.WinScore {
+		return "W"
+	}
+	return fmt.Sprintf("%+.0f", e.Score)
+}
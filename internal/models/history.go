@@ -0,0 +1,50 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+// MoveHistory is the list of moves played in a game, for the move history panel.
+type MoveHistory struct {
+	Moves []HistoryMove
+	// MoveString is the whole game in move notation, e.g. "ddcef".
+	MoveString string
+}
+
+// HistoryMove is a single ply of the game.
+type HistoryMove struct {
+	Ply        int // the ply the move was played on, counting from 1
+	Notation   string
+	Player     string
+	Token      rune
+	ThinkTime  time.Duration
+	ScoreDelta uint64
+}
+
+func NewMoveHistory(game *connectfour.Game) MoveHistory {
+	history := MoveHistory{MoveString: connectfour.FormatMoves(game.Moves)}
+	for i, move := range game.Moves {
+		entry := HistoryMove{
+			Ply:        i + 1,
+			Notation:   connectfour.ColumnName(move.Column),
+			ThinkTime:  move.ThinkTime,
+			ScoreDelta: move.ScoreDelta,
+		}
+		if player, ok := game.FindPlayer(move.PlayerID); ok {
+			entry.Player, entry.Token = player.Name(), player.Token()
+		}
+		history.Moves = append(history.Moves, entry)
+	}
+	return history
+}
+
+// ThinkTimeText is the time taken for the move, to a tenth of a second under a minute.
+func (m HistoryMove) ThinkTimeText() string {
+	if m.ThinkTime < time.Minute {
+		return fmt.Sprintf("%.1fs", m.ThinkTime.Seconds())
+	}
+	return m.ThinkTime.Round(time.Second).String()
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+func TestNewMoveHistory(t *testing.T) {
+	player1, player2 := connectfour.NewHumanPlayerPair()
+
+	tests := []struct {
+		name       string
+		moves      []connectfour.Move
+		wantString string
+		want       []HistoryMove
+	}{
+		{"no moves", nil, "", nil},
+		{
+			"both players",
+			[]connectfour.Move{
+				{Column: 3, PlayerID: player1.ID(), ThinkTime: 1500 * time.Millisecond, ScoreDelta: 4},
+				{Column: 0, PlayerID: player2.ID(), ThinkTime: 2 * time.Minute},
+			},
+			"da",
+			[]HistoryMove{
+				{Ply: 1, Notation: "d", Player: "Player1", Token: 'X', ThinkTime: 1500 * time.Millisecond, ScoreDelta: 4},
+				{Ply: 2, Notation: "a", Player: "Player2", Token: 'O', ThinkTime: 2 * time.Minute},
+			},
+		},
+		{
+			"unknown player",
+			[]connectfour.Move{{Column: 6, PlayerID: "someone else"}},
+			"g",
+			[]HistoryMove{{Ply: 1, Notation: "g"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := connectfour.NewGame(player1, player2)
+			game.Moves = tt.moves
+
+			history := NewMoveHistory(game)
+			if history.MoveString != tt.wantString {
+				t.Errorf("expected the move string %q, got %q", tt.wantString, history.MoveString)
+			}
+			if !reflect.DeepEqual(history.Moves, tt.want) {
+				t.Errorf("expected moves %+v, got %+v", tt.want, history.Moves)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import "github.com/Zach51920/connect-four/internal/repository"
+
+const (
+	LeaderboardWindowWeek  = "week"
+	LeaderboardWindowMonth = "month"
+	LeaderboardWindowAll   = "all"
+
+	LeaderboardLimit = 25
+)
+
+var LeaderboardWindows = []string{LeaderboardWindowWeek, LeaderboardWindowMonth, LeaderboardWindowAll}
+
+type LeaderboardRequest struct {
+	View   string `form:"view"`
+	Window string `form:"window"`
+}
+
+type Leaderboard struct {
+	View    string                        `json:"view"`
+	Window  string                        `json:"window"`
+	Entries []repository.LeaderboardEntry `json:"entries"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// events pushed to a session while it's in the matchmaking queue
+const (
+	EventQueueUpdate  = "queue-update"
+	EventQueueTimeout = "queue-timeout"
+	EventMatchFound   = "match-found"
+)
+
+// QueueStatus is the progress of a session waiting for an online opponent.
+type QueueStatus struct {
+	TimeControl string        `json:"time_control"`
+	Rating      int           `json:"rating"`
+	Waiting     time.Duration `json:"waiting"`
+	Band        int           `json:"band"`
+	Queued      int           `json:"queued"`
+}
+
+func (s QueueStatus) WaitingTime() string {
+	seconds := int(s.Waiting.Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// MatchFound tells a session which game it was matched into and which player it controls.
+type MatchFound struct {
+	GameID   string `json:"game_id"`
+	PlayerID string `json:"player_id"`
+	Opponent string `json:"opponent"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "github.com/Zach51920/connect-four/internal/connectfour"
+
+const DefaultReplaySpeed = 800 // milliseconds between autoplay moves
+
+// ReplaySpeeds are the speeds a replay can be played at, slowest first.
+var ReplaySpeeds = []int{1600, 800, 400, 200}
+
+type GameReplay struct {
+	Game  *connectfour.Game
+	Ply   int
+	Plies int
+	Speed int
+}
+
+func (r *GameReplay) HasPrev() bool { return r.Ply > 0 }
+
+func (r *GameReplay) HasNext() bool { return r.Ply < r.Plies }
@@ -11,7 +11,9 @@ type MakeMoveRequest struct {
 }
 
 type CreateGameRequest struct {
-	Type string `form:"game_type"`
+	Type     string `form:"game_type"`
+	BestOf   int    `form:"best_of"`
+	Strategy string `form:"strategy"`
 }
 
 type BotConfigRequest struct {
@@ -19,4 +21,48 @@ type BotConfigRequest struct {
 	Difficulty       int    `form:"difficulty"`
 	MistakeFrequency int    `form:"mistake_frequency"`
 	IsRandom         string `form:"is_random"`
+	Seed             *int64 `form:"seed"`
+}
+
+type ReplayRequest struct {
+	Ply   *int `form:"ply"`
+	Speed int  `form:"speed"`
+}
+
+type ImageRequest struct {
+	Ply         *int   `form:"ply"`
+	Moves       string `form:"moves"`
+	Coordinates bool   `form:"coords"`
+	Theme       string `form:"theme"`
+}
+
+type ReplayGIFRequest struct {
+	Delay       int    `form:"delay"` // milliseconds between frames
+	Coordinates bool   `form:"coords"`
+	Theme       string `form:"theme"`
+}
+
+type MovePreviewRequest struct {
+	Ply int `form:"ply"`
+}
+
+type LoginRequest struct {
+	Username string `form:"username"`
+	Password string `form:"password"`
+}
+
+type RegisterRequest struct {
+	Username    string `form:"username"`
+	Password    string `form:"password"`
+	DisplayName string `form:"display_name"`
+}
+
+type ProfileRequest struct {
+	DisplayName string `form:"display_name"`
+	Color       string `form:"color"`
+}
+
+type MatchmakingRequest struct {
+	TimeControl string `form:"time_control"`
+	Name        string `form:"name"` // only used for players without an account
 }
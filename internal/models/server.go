@@ -0,0 +1,9 @@
+package models
+
+// EventServerRestarting is sent to every stream just before it's closed for a shutdown.
+const EventServerRestarting = "server-restarting"
+
+// ServerRestarting tells clients why their stream is about to close.
+type ServerRestarting struct {
+	Message string `json:"message"`
+}
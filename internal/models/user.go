@@ -0,0 +1,16 @@
+package models
+
+const (
+	ColorRed    = "red"
+	ColorYellow = "yellow"
+)
+
+var Colors = []string{ColorRed, ColorYellow}
+
+// ColorToken maps a colour preference to the token that is drawn in that colour.
+func ColorToken(color string) rune {
+	if color == ColorYellow {
+		return 'O'
+	}
+	return 'X'
+}
@@ -65,7 +65,7 @@ func (p *Provider) Ping() error {
 
 	var result bson.D
 	return p.DB().
-		RunCommand(ctx, bson.D{{"ping", 1}}).
+		RunCommand(ctx, bson.D{{Key: "ping", Value: 1}}).
 		Decode(&result)
 }
 
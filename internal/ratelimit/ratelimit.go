@@ -0,0 +1,72 @@
+// Package ratelimit limits how often clients can make requests.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// idleTimeout is how long a key's bucket is kept after its last request, a bucket that's been
+// idle this long has refilled anyway.
+const idleTimeout = 5 * time.Minute
+
+// Config is a token bucket, Rate requests a second are allowed on average with bursts of up to
+// Burst requests.
+type Config struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// Limiter keeps a token bucket per key, like a session ID or client IP.
+type Limiter struct {
+	config Config
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func NewLimiter(config Config) *Limiter {
+	return &Limiter{
+		config:    config,
+		now:       time.Now,
+		buckets:   make(map[string]*bucket),
+		lastPrune: time.Now(),
+	}
+}
+
+// Allow takes a token from the key's bucket, it reports false when the bucket is empty.
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastPrune) > idleTimeout {
+		l.prune(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(l.config.Rate), l.config.Burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+	return b.limiter.AllowN(now, 1)
+}
+
+func (l *Limiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastPrune = now
+}
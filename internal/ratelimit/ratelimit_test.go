@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	start := time.Now()
+	tests := []struct {
+		name     string
+		config   Config
+		requests []time.Duration // offsets from the start
+		key      func(i int) string
+		want     []bool
+	}{
+		{
+			name:     "burst then empty",
+			config:   Config{Rate: 1, Burst: 2},
+			requests: []time.Duration{0, 0, 0},
+			want:     []bool{true, true, false},
+		},
+		{
+			name:     "refills over time",
+			config:   Config{Rate: 2, Burst: 1},
+			requests: []time.Duration{0, 0, 500 * time.Millisecond, 600 * time.Millisecond},
+			want:     []bool{true, false, true, false},
+		},
+		{
+			name:     "keys have their own bucket",
+			config:   Config{Rate: 1, Burst: 1},
+			requests: []time.Duration{0, 0, 0},
+			key:      func(i int) string { return []string{"a", "b", "a"}[i] },
+			want:     []bool{true, true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewLimiter(tt.config)
+			for i, offset := range tt.requests {
+				limiter.now = func() time.Time { return start.Add(offset) }
+				key := "key"
+				if tt.key != nil {
+					key = tt.key(i)
+				}
+				if got := limiter.Allow(key); got != tt.want[i] {
+					t.Errorf("request %d: expected allowed=%v, got %v", i, tt.want[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiter_Prune(t *testing.T) {
+	now := time.Now()
+	limiter := NewLimiter(Config{Rate: 1, Burst: 1})
+	limiter.now = func() time.Time { return now }
+	limiter.Allow("idle")
+
+	now = now.Add(2 * idleTimeout)
+	limiter.Allow("active")
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Error("expected the idle bucket to be pruned")
+	}
+	if _, ok := limiter.buckets["active"]; !ok {
+		t.Error("expected the active bucket to be kept")
+	}
+}
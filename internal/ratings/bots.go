@@ -0,0 +1,98 @@
+package ratings
+
+import (
+	"fmt"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+const (
+	KindHuman = "human"
+	KindBot   = "bot"
+
+	// bots start with a lower deviation since their initial rating is calibrated
+	botDeviation = 150.0
+)
+
+// difficultyRatings are the starting ratings for each minimax difficulty with no mistakes or
+// randomization. Bots move away from these as they play, run cmd/tournament to recalibrate.
+var difficultyRatings = map[int]float64{
+	1:  900,
+	2:  1050,
+	3:  1200,
+	4:  1300,
+	5:  1400,
+	6:  1500,
+	7:  1600,
+	8:  1700,
+	9:  1800,
+	10: 1900,
+}
+
+// HumanID is the rating subject for a human player, only players with an account are rated.
+func HumanID(player *connectfour.HumanPlayer) string {
+	if player.UserID() == "" {
+		return ""
+	}
+	return UserID(player.UserID())
+}
+
+// UserID is the rating subject for a user account.
+func UserID(userID string) string {
+	return "user:" + userID
+}
+
+// BotID is the rating subject for a bot configuration. Bots with the same strategy and
+// settings share a rating no matter their name or seed.
+func BotID(bot *connectfour.BotPlayer) string {
+	return fmt.Sprintf("bot:%s:d%d:m%d:r%t", bot.Strategy(), bot.Config.Difficulty, bot.Config.MistakeFrequency, bot.Config.Randomize)
+}
+
+// BotName is a readable name for the bot configuration.
+func BotName(bot *connectfour.BotPlayer) string {
+	name := fmt.Sprintf("%s difficulty %d, %d%% mistakes", bot.Strategy(), bot.Config.Difficulty, bot.Config.MistakeFrequency)
+	if bot.Config.Randomize {
+		name += ", randomized"
+	}
+	return name
+}
+
+// BotRating is the calibrated starting rating for a bot configuration.
+func BotRating(config *connectfour.Config) Rating {
+	rating, ok := difficultyRatings[config.Difficulty]
+	if !ok {
+		rating = difficultyRatings[1]
+		if config.Difficulty > 10 {
+			rating = difficultyRatings[10]
+		}
+	}
+
+	// the bot makes a mistake with a 1 in (101 - frequency) chance each move
+	mistakeChance := 1 / float64(101-min(max(config.MistakeFrequency, 0), 100))
+	rating -= 800 * mistakeChance
+	if config.Randomize {
+		rating -= 25
+	}
+	return Rating{Rating: rating, Deviation: botDeviation, Volatility: DefaultVolatility}
+}
+
+// SubjectID returns the rating subject, kind, and initial rating for any player. The ID is
+// empty if the player isn't rated.
+func SubjectID(player connectfour.Player) (id, kind string, initial Rating) {
+	switch p := player.(type) {
+	case *connectfour.BotPlayer:
+		return BotID(p), KindBot, BotRating(p.Config)
+	case *connectfour.HumanPlayer:
+		return HumanID(p), KindHuman, NewRating()
+	default:
+		return "", "", NewRating()
+	}
+}
+
+// SubjectName returns the name to store alongside a rating.
+func SubjectName(player connectfour.Player) string {
+	if bot, ok := player.(*connectfour.BotPlayer); ok {
+		return BotName(bot)
+	}
+	return player.Name()
+}
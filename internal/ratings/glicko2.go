@@ -0,0 +1,109 @@
+package ratings
+
+import "math"
+
+const (
+	DefaultRating     = 1500.0
+	DefaultDeviation  = 350.0
+	DefaultVolatility = 0.06
+
+	// tau constrains how quickly volatility can change, glickman recommends 0.3 to 1.2
+	tau       = 0.5
+	glickoQ   = 173.7178
+	tolerance = 0.000001
+)
+
+const (
+	ScoreLoss = 0.0
+	ScoreDraw = 0.5
+	ScoreWin  = 1.0
+)
+
+// Rating is a Glicko-2 rating on the familiar Glicko scale.
+type Rating struct {
+	Rating     float64
+	Deviation  float64
+	Volatility float64
+}
+
+func NewRating() Rating {
+	return Rating{Rating: DefaultRating, Deviation: DefaultDeviation, Volatility: DefaultVolatility}
+}
+
+// Update returns the new rating after a single game against the opponent, score is 1 for a win,
+// 0.5 for a draw and 0 for a loss. Every game is treated as its own rating period.
+func Update(player, opponent Rating, score float64) Rating {
+	mu, phi := toGlicko2(player)
+	opMu, opPhi := toGlicko2(opponent)
+
+	g := gFunc(opPhi)
+	e := expected(mu, opMu, g)
+	v := 1 / (g * g * e * (1 - e))
+	delta := v * g * (score - e)
+
+	sigma := newVolatility(phi, v, delta, player.Volatility)
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*g*(score-e)
+
+	return Rating{
+		Rating:     glickoQ*newMu + DefaultRating,
+		Deviation:  glickoQ * newPhi,
+		Volatility: sigma,
+	}
+}
+
+// Expected returns the expected score of the player against the opponent.
+func Expected(player, opponent Rating) float64 {
+	mu, _ := toGlicko2(player)
+	opMu, opPhi := toGlicko2(opponent)
+	return expected(mu, opMu, gFunc(opPhi))
+}
+
+func toGlicko2(r Rating) (mu, phi float64) {
+	return (r.Rating - DefaultRating) / glickoQ, r.Deviation / glickoQ
+}
+
+func gFunc(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func expected(mu, opMu, g float64) float64 {
+	return 1 / (1 + math.Exp(-g*(mu-opMu)))
+}
+
+// newVolatility finds the new volatility with the Illinois algorithm from step 5 of the Glicko-2 paper.
+func newVolatility(phi, v, delta, sigma float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		num := ex * (delta*delta - phi*phi - v - ex)
+		den := 2 * math.Pow(phi*phi+v+ex, 2)
+		return num/den - (x-a)/(tau*tau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		B = a - k*tau
+	}
+
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > tolerance {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	return math.Exp(A / 2)
+}
@@ -0,0 +1,27 @@
+package ratings
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	// the first opponent from the example in the Glicko-2 paper, played as its own rating period
+	player := Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+	opponent := Rating{Rating: 1400, Deviation: 30, Volatility: 0.06}
+
+	won := Update(player, opponent, ScoreWin)
+	if math.Abs(won.Rating-1563.6) > 0.1 || math.Abs(won.Deviation-175.4) > 0.1 {
+		t.Errorf("unexpected rating after win: %+v", won)
+	}
+
+	lost := Update(player, opponent, ScoreLoss)
+	if lost.Rating >= player.Rating || lost.Deviation >= player.Deviation {
+		t.Errorf("expected rating and deviation to drop after a loss: %+v", lost)
+	}
+
+	drawn := Update(player, player, ScoreDraw)
+	if math.Abs(drawn.Rating-player.Rating) > 0.0001 {
+		t.Errorf("expected a draw between equals to keep the rating, got %f", drawn.Rating)
+	}
+}
@@ -0,0 +1,32 @@
+package repository
+
+import "time"
+
+const (
+	LeaderboardRatings = "ratings"
+	LeaderboardWins    = "wins"
+	LeaderboardStreak  = "streak"
+	LeaderboardScore   = "score"
+	LeaderboardVsBots  = "vs_bots"
+)
+
+var LeaderboardViews = []string{LeaderboardRatings, LeaderboardWins, LeaderboardStreak, LeaderboardScore, LeaderboardVsBots}
+
+type LeaderboardQuery struct {
+	View  string
+	Since time.Time // zero for all-time
+	Limit int
+}
+
+// LeaderboardEntry is a single row of a leaderboard. Value holds whatever the view ranks by:
+// the rating, number of wins, longest streak, best game score, or win rate against a bot difficulty.
+type LeaderboardEntry struct {
+	PlayerID   string  `bson:"player_id" json:"player_id"`
+	Name       string  `bson:"name" json:"name"`
+	Kind       string  `bson:"kind,omitempty" json:"kind,omitempty"`
+	Value      float64 `bson:"value" json:"value"`
+	Wins       int     `bson:"wins" json:"wins"`
+	Draws      int     `bson:"draws" json:"draws"`
+	Losses     int     `bson:"losses" json:"losses"`
+	Difficulty int     `bson:"difficulty,omitempty" json:"difficulty,omitempty"`
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/metrics"
+)
+
+const (
+	backendMongoDB = "mongodb"
+	backendSQLite  = "sqlite"
+)
+
+// observeSave records how long a write took and whether it failed, it's deferred at the top of
+// each write so err must point at the named result. Expected errors like a taken username
+// aren't counted as failures.
+func observeSave(backend, operation string, start time.Time, err *error) {
+	metrics.RepositorySaveDuration.WithLabelValues(backend, operation).Observe(time.Since(start).Seconds())
+	if *err != nil && !errors.Is(*err, ErrUsernameTaken) && !errors.Is(*err, ErrUserNotFound) &&
+		!errors.Is(*err, ErrRatingConflict) {
+		metrics.RepositorySaveErrors.WithLabelValues(backend, operation).Inc()
+	}
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveSave(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantFailed bool
+	}{
+		{"success", nil, false},
+		{"failure", errors.New("disk full"), true},
+		{"username taken", ErrUsernameTaken, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operation := "test_" + tt.name
+			errs := metrics.RepositorySaveErrors.WithLabelValues(backendSQLite, operation)
+			before := testutil.ToFloat64(errs)
+
+			err := tt.err
+			observeSave(backendSQLite, operation, time.Now(), &err)
+
+			failed := testutil.ToFloat64(errs) - before
+			if (failed == 1) != tt.wantFailed {
+				t.Errorf("expected failure counted %t, counter went up by %v", tt.wantFailed, failed)
+			}
+			if count := testutil.CollectAndCount(metrics.RepositorySaveDuration, "connectfour_repository_save_seconds"); count == 0 {
+				t.Error("expected the save duration to be observed")
+			}
+		})
+	}
+}
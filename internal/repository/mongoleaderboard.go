@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (r *MongoRepository) Leaderboard(ctx context.Context, query LeaderboardQuery) ([]LeaderboardEntry, error) {
+	mongoCtx, ctxCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer ctxCancel()
+
+	var collection *mongo.Collection
+	var pipeline mongo.Pipeline
+	switch query.View {
+	case LeaderboardRatings:
+		collection, pipeline = r.ratings, ratingsPipeline(query)
+	case LeaderboardWins, LeaderboardStreak, LeaderboardScore, LeaderboardVsBots:
+		collection, pipeline = r.collection, resultsPipeline(query)
+	default:
+		return nil, fmt.Errorf("unknown leaderboard view: %s", query.View)
+	}
+
+	cursor, err := collection.Aggregate(mongoCtx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]LeaderboardEntry, 0, query.Limit)
+	if err = cursor.All(mongoCtx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// finishedResults are the results of a decided game, unfinished games have none.
+var finishedResults = bson.A{
+	connectfour.ResultConnectFour,
+	connectfour.ResultBoardFull,
+	connectfour.ResultResignation,
+	connectfour.ResultAgreement,
+}
+
+func ratingsPipeline(query LeaderboardQuery) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"updated_at": bson.M{"$gte": query.Since}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "rating", Value: -1}}}},
+		{{Key: "$limit", Value: query.Limit}},
+		{{Key: "$project", Value: bson.M{
+			"_id":       0,
+			"player_id": "$_id",
+			"name":      1,
+			"kind":      1,
+			"value":     "$rating",
+		}}},
+	}
+}
+
+// resultsPipeline turns finished games into one document per human player and game, then
+// groups those into the requested leaderboard view.
+func resultsPipeline(query LeaderboardQuery) mongo.Pipeline {
+	pipeline := mongo.Pipeline{
+		// only count games that were decided, on the board or off it
+		{{Key: "$match", Value: bson.M{
+			"timestamp": bson.M{"$gte": query.Since},
+			"result":    bson.M{"$in": finishedResults},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"timestamp": 1,
+			"winner":    1,
+			"sides": bson.A{
+				bson.M{"me": "$player1", "op": "$player2"},
+				bson.M{"me": "$player2", "op": "$player1"},
+			},
+		}}},
+		{{Key: "$unwind", Value: "$sides"}},
+		// only players with an account make the leaderboard
+		{{Key: "$match", Value: bson.M{
+			"sides.me.strategy": connectfour.StrategyHuman,
+			"sides.me.user_id":  bson.M{"$exists": true},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "timestamp", Value: 1}}}},
+		{{Key: "$addFields", Value: bson.M{
+			"won":   bson.M{"$eq": bson.A{"$winner", "$sides.me.id"}},
+			"drawn": bson.M{"$not": bson.A{bson.M{"$ifNull": bson.A{"$winner", false}}}},
+		}}},
+	}
+
+	record := bson.M{
+		"name":   bson.M{"$last": "$sides.me.name"},
+		"wins":   bson.M{"$sum": bson.M{"$cond": bson.A{"$won", 1, 0}}},
+		"draws":  bson.M{"$sum": bson.M{"$cond": bson.A{"$drawn", 1, 0}}},
+		"losses": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$or": bson.A{"$won", "$drawn"}}, 0, 1}}},
+	}
+	project := bson.M{"_id": 0, "player_id": "$_id", "name": 1, "wins": 1, "draws": 1, "losses": 1}
+
+	switch query.View {
+	case LeaderboardWins:
+		record["_id"] = "$sides.me.id"
+		project["value"] = "$wins"
+		pipeline = append(pipeline,
+			bson.D{{Key: "$group", Value: record}},
+			bson.D{{Key: "$project", Value: project}},
+		)
+	case LeaderboardScore:
+		record["_id"] = "$sides.me.id"
+		record["best_score"] = bson.M{"$max": "$sides.me.score"}
+		project["value"] = "$best_score"
+		pipeline = append(pipeline,
+			bson.D{{Key: "$group", Value: record}},
+			bson.D{{Key: "$project", Value: project}},
+		)
+	case LeaderboardStreak:
+		// walk the games in order, keeping the current and the longest run of wins
+		record["_id"] = "$sides.me.id"
+		record["results"] = bson.M{"$push": "$won"}
+		next := bson.M{"$cond": bson.A{"$$this", bson.M{"$add": bson.A{"$$value.current", 1}}, 0}}
+		project["value"] = bson.M{"$reduce": bson.M{
+			"input":        "$results",
+			"initialValue": bson.M{"current": 0, "best": 0},
+			"in": bson.M{
+				"current": next,
+				"best":    bson.M{"$max": bson.A{"$$value.best", next}},
+			},
+		}}
+		pipeline = append(pipeline,
+			bson.D{{Key: "$group", Value: record}},
+			bson.D{{Key: "$project", Value: project}},
+			bson.D{{Key: "$set", Value: bson.M{"value": "$value.best"}}},
+		)
+	case LeaderboardVsBots:
+		// group each players record by the bots difficulty, then keep the best record per difficulty
+		record["_id"] = bson.M{"id": "$sides.me.id", "difficulty": "$sides.op.bot.difficulty"}
+		project["player_id"] = "$_id.id"
+		project["difficulty"] = "$_id.difficulty"
+		project["value"] = bson.M{"$divide": bson.A{"$wins", bson.M{"$add": bson.A{"$wins", "$draws", "$losses"}}}}
+		pipeline = append(pipeline,
+			bson.D{{Key: "$match", Value: bson.M{"sides.op.bot": bson.M{"$exists": true}}}},
+			bson.D{{Key: "$group", Value: record}},
+			bson.D{{Key: "$project", Value: project}},
+			bson.D{{Key: "$sort", Value: bson.D{{Key: "value", Value: -1}, {Key: "wins", Value: -1}}}},
+			bson.D{{Key: "$group", Value: bson.M{"_id": "$difficulty", "best": bson.M{"$first": "$$ROOT"}}}},
+			bson.D{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$best"}}},
+			bson.D{{Key: "$sort", Value: bson.D{{Key: "difficulty", Value: 1}}}},
+			bson.D{{Key: "$limit", Value: query.Limit}},
+		)
+		return pipeline
+	}
+
+	return append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "value", Value: -1}, {Key: "wins", Value: -1}}}},
+		bson.D{{Key: "$limit", Value: query.Limit}},
+	)
+}
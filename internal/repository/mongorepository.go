@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"time"
 
 	"github.com/Zach51920/connect-four/internal/connectfour"
@@ -10,51 +12,247 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// usernameCollation makes username lookups and uniqueness case-insensitive
+var usernameCollation = &options.Collation{Locale: "en", Strength: 2}
+
 type MongoRepository struct {
-	collection *mongo.Collection
+	collection    *mongo.Collection
+	series        *mongo.Collection
+	ratings       *mongo.Collection
+	ratingHistory *mongo.Collection
+	users         *mongo.Collection
+	sessions      *mongo.Collection
 }
 
 func NewMongoRepository(db *mongo.Database) *MongoRepository {
-	return &MongoRepository{collection: db.Collection("games")}
+	repo := &MongoRepository{
+		collection:    db.Collection("games"),
+		series:        db.Collection("series"),
+		ratings:       db.Collection("ratings"),
+		ratingHistory: db.Collection("rating_history"),
+		users:         db.Collection("users"),
+		sessions:      db.Collection("suspended_sessions"),
+	}
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "username", Value: 1}},
+		Options: options.Index().SetUnique(true).SetCollation(usernameCollation),
+	}
+	if _, err := repo.users.Indexes().CreateOne(ctx, index); err != nil {
+		slog.Error("failed to create username index", "error", err)
+	}
+	if err := repo.backfillResults(ctx); err != nil {
+		slog.Error("failed to backfill game results", "error", err)
+	}
+	return repo
+}
+
+// backfillResults sets the result of games saved before results were stored, they were either
+// won on the board or drawn on a full board.
+func (r *MongoRepository) backfillResults(ctx context.Context) error {
+	boardSize := bson.M{"$multiply": bson.A{
+		bson.M{"$ifNull": bson.A{"$rows", connectfour.DefaultBoardRows}},
+		bson.M{"$ifNull": bson.A{"$cols", connectfour.DefaultBoardColumns}},
+	}}
+	won := bson.M{"result": bson.M{"$exists": false}, "winner": bson.M{"$exists": true}}
+	if _, err := r.collection.UpdateMany(ctx, won, bson.M{"$set": bson.M{"result": connectfour.ResultConnectFour}}); err != nil {
+		return err
+	}
+	full := bson.M{"result": bson.M{"$exists": false}, "$expr": bson.M{"$gte": bson.A{"$move_count", boardSize}}}
+	_, err := r.collection.UpdateMany(ctx, full, bson.M{"$set": bson.M{"result": connectfour.ResultBoardFull}})
+	return err
 }
 
-func (r *MongoRepository) SaveMove(ctx context.Context, game *connectfour.Game, player connectfour.Player, column int) error {
+func (r *MongoRepository) SaveMove(ctx context.Context, game *connectfour.Game, player connectfour.Player, column int) (err error) {
+	defer observeSave(backendMongoDB, "save_move", time.Now(), &err)
 	mongoCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
 	defer ctxCancel()
 
-	move := Move{
-		ID:       game.MoveCount,
-		Column:   column,
-		PlayerID: player.ID(),
-	}
+	move := mapMove(game, player, column)
 
 	update := bson.M{
 		"$setOnInsert": bson.M{
 			"_id":       game.ID,
+			"variant":   game.Variant,
+			"rows":      game.Board.NumRows(),
+			"cols":      game.Board.NumCols(),
 			"timestamp": time.Now(),
 		},
 		"$push": bson.M{"moves": move},
 		"$inc":  bson.M{"move_count": 1},
 		"$set": bson.M{
-			"player1": mapPlayer(game.Players[0]),
-			"player2": mapPlayer(game.Players[1]),
+			"player1": mapPlayer(game, 0),
+			"player2": mapPlayer(game, 1),
 		},
 	}
 
 	if game.Winner != nil {
 		update["$set"].(bson.M)["winner"] = game.Winner.ID()
 	}
+	if game.Result != "" {
+		update["$set"].(bson.M)["result"] = game.Result
+	}
+	if inSeries(game) {
+		update["$setOnInsert"].(bson.M)["series_id"] = game.Series.ID
+		update["$setOnInsert"].(bson.M)["series_game"] = game.Series.GameNumber()
+	}
 
 	opts := options.Update().SetUpsert(true)
-	_, err := r.collection.UpdateOne(mongoCtx, bson.M{"_id": game.ID}, update, opts)
+	_, err = r.collection.UpdateOne(mongoCtx, bson.M{"_id": game.ID}, update, opts)
+	return err
+}
+
+func (r *MongoRepository) SaveResult(ctx context.Context, game *connectfour.Game) (err error) {
+	defer observeSave(backendMongoDB, "save_result", time.Now(), &err)
+	mongoCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	set := bson.M{"result": game.Result}
+	if game.Winner != nil {
+		set["winner"] = game.Winner.ID()
+	}
+	if id := resignedBy(game); id != "" {
+		set["resigned_by"] = id
+	}
+	_, err = r.collection.UpdateOne(mongoCtx, bson.M{"_id": game.ID}, bson.M{"$set": set})
+	return err
+}
+
+func (r *MongoRepository) GetGame(ctx context.Context, id string) (*Game, error) {
+	mongoCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	game := new(Game)
+	err := r.collection.FindOne(mongoCtx, bson.M{"_id": id}).Decode(game)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrGameNotFound
+	}
+	return game, err
+}
+
+func (r *MongoRepository) SaveSeries(ctx context.Context, series *connectfour.Series) (err error) {
+	defer observeSave(backendMongoDB, "save_series", time.Now(), &err)
+	mongoCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.series.ReplaceOne(mongoCtx, bson.M{"_id": series.ID}, mapSeries(series), opts)
+	return err
+}
+
+func (r *MongoRepository) GetSeries(ctx context.Context, id string) (*Series, error) {
+	mongoCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	series := new(Series)
+	err := r.series.FindOne(mongoCtx, bson.M{"_id": id}).Decode(series)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSeriesNotFound
+	}
+	return series, err
+}
+
+func (r *MongoRepository) GetRating(ctx context.Context, subjectID string) (*Rating, error) {
+	mongoCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	rating := new(Rating)
+	err := r.ratings.FindOne(mongoCtx, bson.M{"_id": subjectID}).Decode(rating)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrRatingNotFound
+	}
+	return rating, err
+}
+
+func (r *MongoRepository) SaveRating(ctx context.Context, rating *Rating, history RatingHistory) (err error) {
+	defer observeSave(backendMongoDB, "save_rating", time.Now(), &err)
+	mongoCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	// the games count is the rating's version, a rating that was changed since the read doesn't
+	// match so the upsert tries to insert it again and hits the duplicate ID
+	filter := bson.M{"_id": rating.SubjectID, "games": rating.Games - 1}
+	opts := options.Replace().SetUpsert(true)
+	_, err = r.ratings.ReplaceOne(mongoCtx, filter, rating, opts)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrRatingConflict
+	} else if err != nil {
+		return err
+	}
+	_, err = r.ratingHistory.InsertOne(mongoCtx, history)
+	return err
+}
+
+func (r *MongoRepository) CreateUser(ctx context.Context, user *User) (err error) {
+	defer observeSave(backendMongoDB, "create_user", time.Now(), &err)
+	mongoCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	_, err = r.users.InsertOne(mongoCtx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrUsernameTaken
+	}
+	return err
+}
+
+func (r *MongoRepository) GetUser(ctx context.Context, id string) (*User, error) {
+	return r.findUser(ctx, bson.M{"_id": id})
+}
+
+func (r *MongoRepository) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	return r.findUser(ctx, bson.M{"username": username})
+}
+
+func (r *MongoRepository) findUser(ctx context.Context, filter bson.M) (*User, error) {
+	mongoCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	user := new(User)
+	opts := options.FindOne().SetCollation(usernameCollation)
+	err := r.users.FindOne(mongoCtx, filter, opts).Decode(user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *MongoRepository) UpdateUser(ctx context.Context, user *User) (err error) {
+	defer observeSave(backendMongoDB, "update_user", time.Now(), &err)
+	mongoCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	result, err := r.users.ReplaceOne(mongoCtx, bson.M{"_id": user.ID}, user)
+	if err != nil {
+		return err
+	} else if result.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *MongoRepository) SaveSession(ctx context.Context, session *SuspendedSession) (err error) {
+	defer observeSave(backendMongoDB, "save_session", time.Now(), &err)
+	mongoCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	_, err = r.sessions.ReplaceOne(mongoCtx, bson.M{"_id": session.ID}, session, options.Replace().SetUpsert(true))
 	return err
 }
 
-func mapPlayer(player connectfour.Player) Player {
-	return Player{
-		ID:       player.ID(),
-		Strategy: player.Strategy(),
-		Token:    player.Token(),
-		Score:    player.Score(),
+func (r *MongoRepository) TakeSession(ctx context.Context, id string) (*SuspendedSession, error) {
+	mongoCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	session := new(SuspendedSession)
+	err := r.sessions.FindOneAndDelete(mongoCtx, bson.M{"_id": id}).Decode(session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSessionNotFound
 	}
+	return session, err
+}
+
+func (r *MongoRepository) Ping(ctx context.Context) error {
+	return r.collection.Database().Client().Ping(ctx, nil)
 }
@@ -2,21 +2,152 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"github.com/Zach51920/connect-four/internal/connectfour"
 	"log/slog"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrGameNotFound    = errors.New("game not found")
+	ErrRatingNotFound  = errors.New("rating not found")
+	ErrRatingConflict  = errors.New("rating was changed by another game")
+	ErrUserNotFound    = errors.New("user not found")
+	ErrUsernameTaken   = errors.New("username is taken")
+	ErrSeriesNotFound  = errors.New("series not found")
+	ErrSessionNotFound = errors.New("session not found")
 )
 
 type Repository interface {
 	SaveMove(ctx context.Context, game *connectfour.Game, player connectfour.Player, column int) error
+	// SaveResult records how a game that ended without a move was decided, like a resignation.
+	// Games without any saved moves aren't stored so there's nothing to update.
+	SaveResult(ctx context.Context, game *connectfour.Game) error
+	GetGame(ctx context.Context, id string) (*Game, error)
+	SaveSeries(ctx context.Context, series *connectfour.Series) error
+	GetSeries(ctx context.Context, id string) (*Series, error)
+	GetRating(ctx context.Context, subjectID string) (*Rating, error)
+	// SaveRating replaces a rating read before its latest game was added, so rating.Games must be
+	// one more than the stored rating's. If another game was rated since the read it returns
+	// ErrRatingConflict and nothing is saved.
+	SaveRating(ctx context.Context, rating *Rating, history RatingHistory) error
+	Leaderboard(ctx context.Context, query LeaderboardQuery) ([]LeaderboardEntry, error)
+	CreateUser(ctx context.Context, user *User) error
+	GetUser(ctx context.Context, id string) (*User, error)
+	GetUserByUsername(ctx context.Context, username string) (*User, error)
+	UpdateUser(ctx context.Context, user *User) error
+	// SaveSession remembers the game a session was playing when the server shut down.
+	SaveSession(ctx context.Context, session *SuspendedSession) error
+	// TakeSession returns the game a session was playing and forgets it, so it's only restored once.
+	TakeSession(ctx context.Context, id string) (*SuspendedSession, error)
+	// Ping checks the database can be reached.
+	Ping(ctx context.Context) error
 }
 
-type MockRepository struct{}
+// MockRepository doesn't save anything except for users, which are kept in memory so accounts
+// still work without a database.
+type MockRepository struct {
+	usersMu sync.RWMutex
+	users   map[string]User
+}
 
 func NewMockRepository() *MockRepository {
-	return &MockRepository{}
+	return &MockRepository{users: make(map[string]User)}
 }
 
 func (r *MockRepository) SaveMove(ctx context.Context, game *connectfour.Game, player connectfour.Player, column int) error {
 	slog.Debug("MOCK_REPO: save move")
 	return nil
 }
+
+func (r *MockRepository) SaveResult(ctx context.Context, game *connectfour.Game) error {
+	slog.Debug("MOCK_REPO: save result", "game_id", game.ID, "result", game.Result)
+	return nil
+}
+
+func (r *MockRepository) SaveSeries(ctx context.Context, series *connectfour.Series) error {
+	slog.Debug("MOCK_REPO: save series", "series_id", series.ID)
+	return nil
+}
+
+func (r *MockRepository) GetSeries(ctx context.Context, id string) (*Series, error) {
+	slog.Debug("MOCK_REPO: get series", "series_id", id)
+	return nil, ErrSeriesNotFound
+}
+
+func (r *MockRepository) GetGame(ctx context.Context, id string) (*Game, error) {
+	slog.Debug("MOCK_REPO: get game", "game_id", id)
+	return nil, ErrGameNotFound
+}
+
+func (r *MockRepository) GetRating(ctx context.Context, subjectID string) (*Rating, error) {
+	slog.Debug("MOCK_REPO: get rating", "subject_id", subjectID)
+	return nil, ErrRatingNotFound
+}
+
+func (r *MockRepository) SaveRating(ctx context.Context, rating *Rating, history RatingHistory) error {
+	slog.Debug("MOCK_REPO: save rating", "subject_id", rating.SubjectID, "rating", rating.Rating)
+	return nil
+}
+
+func (r *MockRepository) Leaderboard(ctx context.Context, query LeaderboardQuery) ([]LeaderboardEntry, error) {
+	slog.Debug("MOCK_REPO: leaderboard", "view", query.View)
+	return nil, nil
+}
+
+func (r *MockRepository) CreateUser(ctx context.Context, user *User) error {
+	r.usersMu.Lock()
+	defer r.usersMu.Unlock()
+	for _, existing := range r.users {
+		if strings.EqualFold(existing.Username, user.Username) {
+			return ErrUsernameTaken
+		}
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *MockRepository) GetUser(ctx context.Context, id string) (*User, error) {
+	r.usersMu.RLock()
+	defer r.usersMu.RUnlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (r *MockRepository) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	r.usersMu.RLock()
+	defer r.usersMu.RUnlock()
+	for _, user := range r.users {
+		if strings.EqualFold(user.Username, username) {
+			return &user, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (r *MockRepository) UpdateUser(ctx context.Context, user *User) error {
+	r.usersMu.Lock()
+	defer r.usersMu.Unlock()
+	if _, ok := r.users[user.ID]; !ok {
+		return ErrUserNotFound
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *MockRepository) SaveSession(ctx context.Context, session *SuspendedSession) error {
+	slog.Debug("MOCK_REPO: save session", "session_id", session.ID, "game_id", session.GameID)
+	return nil
+}
+
+func (r *MockRepository) TakeSession(ctx context.Context, id string) (*SuspendedSession, error) {
+	return nil, ErrSessionNotFound
+}
+
+func (r *MockRepository) Ping(ctx context.Context) error {
+	return nil
+}
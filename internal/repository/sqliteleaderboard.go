@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+// sidesCTE turns finished games into one row per human player and game, the sqlite equivalent
+// of the first stages of the mongo results pipeline.
+const sidesCTE = `WITH finished AS (
+		SELECT id, winner_id, timestamp FROM games
+		WHERE julianday(timestamp) >= julianday(?) AND result IN ('connect_four', 'board_full', 'resignation', 'agreement')
+	), sides AS (
+		SELECT
+			g.timestamp,
+			me.id AS player_id,
+			LAST_VALUE(me.name) OVER (
+				PARTITION BY me.id ORDER BY g.timestamp ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING
+			) AS name,
+			me.score,
+			op.bot_difficulty AS difficulty,
+			COALESCE(g.winner_id = me.id, 0) AS won,
+			g.winner_id IS NULL AS drawn,
+			COALESCE(g.winner_id != me.id, 0) AS lost
+		FROM finished g
+		JOIN players me ON me.game_id = g.id AND me.strategy = ? AND me.user_id IS NOT NULL
+		JOIN players op ON op.game_id = g.id AND op.seat != me.seat
+	)`
+
+const leaderboardWinsQuery = sidesCTE + `
+	SELECT player_id, MAX(name), '', SUM(won) AS value, SUM(won) AS wins, SUM(drawn), SUM(lost), 0
+	FROM sides GROUP BY player_id
+	ORDER BY value DESC, wins DESC LIMIT ?`
+
+const leaderboardScoreQuery = sidesCTE + `
+	SELECT player_id, MAX(name), '', MAX(score) AS value, SUM(won) AS wins, SUM(drawn), SUM(lost), 0
+	FROM sides GROUP BY player_id
+	ORDER BY value DESC, wins DESC LIMIT ?`
+
+// the difference between a players game number and their game number among games with the same
+// result is constant for each unbroken run of results
+const leaderboardStreakQuery = sidesCTE + `, islands AS (
+		SELECT player_id, won,
+			ROW_NUMBER() OVER (PARTITION BY player_id ORDER BY timestamp)
+				- ROW_NUMBER() OVER (PARTITION BY player_id, won ORDER BY timestamp) AS island
+		FROM sides
+	), streaks AS (
+		SELECT player_id, MAX(streak) AS streak FROM (
+			SELECT player_id, COUNT(*) AS streak FROM islands WHERE won GROUP BY player_id, island
+		) GROUP BY player_id
+	)
+	SELECT s.player_id, MAX(s.name), '', COALESCE(MAX(st.streak), 0) AS value, SUM(s.won) AS wins, SUM(s.drawn), SUM(s.lost), 0
+	FROM sides s LEFT JOIN streaks st ON st.player_id = s.player_id
+	GROUP BY s.player_id
+	ORDER BY value DESC, wins DESC LIMIT ?`
+
+const leaderboardVsBotsQuery = sidesCTE + `, records AS (
+		SELECT player_id, MAX(name) AS name, difficulty, SUM(won) AS wins, SUM(drawn) AS draws, SUM(lost) AS losses,
+			CAST(SUM(won) AS REAL) / COUNT(*) AS value
+		FROM sides WHERE difficulty IS NOT NULL
+		GROUP BY player_id, difficulty
+	), ranked AS (
+		SELECT *, ROW_NUMBER() OVER (PARTITION BY difficulty ORDER BY value DESC, wins DESC) AS rank FROM records
+	)
+	SELECT player_id, name, '', value, wins, draws, losses, difficulty
+	FROM ranked WHERE rank = 1
+	ORDER BY difficulty LIMIT ?`
+
+const leaderboardRatingsQuery = `SELECT subject_id, name, kind, rating, 0, 0, 0, 0
+	FROM ratings WHERE julianday(updated_at) >= julianday(?)
+	ORDER BY rating DESC LIMIT ?`
+
+func (r *SQLiteRepository) Leaderboard(ctx context.Context, query LeaderboardQuery) ([]LeaderboardEntry, error) {
+	sqlCtx, ctxCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer ctxCancel()
+
+	since := query.Since.UTC()
+	var stmt string
+	args := []any{since, connectfour.StrategyHuman, query.Limit}
+	switch query.View {
+	case LeaderboardRatings:
+		stmt, args = leaderboardRatingsQuery, []any{since, query.Limit}
+	case LeaderboardWins:
+		stmt = leaderboardWinsQuery
+	case LeaderboardScore:
+		stmt = leaderboardScoreQuery
+	case LeaderboardStreak:
+		stmt = leaderboardStreakQuery
+	case LeaderboardVsBots:
+		stmt = leaderboardVsBotsQuery
+	default:
+		return nil, fmt.Errorf("unknown leaderboard view: %s", query.View)
+	}
+
+	rows, err := r.db.QueryContext(sqlCtx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]LeaderboardEntry, 0, query.Limit)
+	for rows.Next() {
+		var entry LeaderboardEntry
+		if err = rows.Scan(&entry.PlayerID, &entry.Name, &entry.Kind, &entry.Value,
+			&entry.Wins, &entry.Draws, &entry.Losses, &entry.Difficulty); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
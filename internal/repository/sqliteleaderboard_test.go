@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/sqlite"
+	"github.com/google/uuid"
+)
+
+func TestSQLiteRepository_Leaderboard(t *testing.T) {
+	provider, err := sqlite.NewProvider(&sqlite.Config{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Close()
+	repo := NewSQLiteRepository(provider.DB())
+	ctx := context.Background()
+
+	var players []connectfour.Player
+	for _, name := range []string{"Alice", "Bob"} {
+		user := &User{ID: uuid.NewString(), Username: name, DisplayName: name, CreatedAt: time.Now()}
+		if err = repo.CreateUser(ctx, user); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+		players = append(players, connectfour.NewUserPlayer(user.ID, name, 'X'))
+	}
+	alice, bob := players[0], players[1]
+	anonymous := connectfour.NewHumanPlayer("Anonymous", 'X')
+	bot3 := connectfour.NewMinimaxBotWithConfig('O', connectfour.DefaultConfig().SetDifficulty(3))
+	bot5 := connectfour.NewMinimaxBotWithConfig('O', connectfour.DefaultConfig().SetDifficulty(5))
+
+	// the first player always wins by stacking column a
+	saveGame := func(first, second connectfour.Player) {
+		first.SetToken('X')
+		second.SetToken('O')
+		game := connectfour.NewGame(first, second)
+		for _, col := range []int{0, 1, 0, 1, 0, 1, 0} {
+			player := game.CurrentPlayer()
+			if _, err := game.Play(player, col); err != nil {
+				t.Fatalf("failed to play move: %v", err)
+			}
+			if err := repo.SaveMove(ctx, game, player, col); err != nil {
+				t.Fatalf("failed to save move: %v", err)
+			}
+			game.NextPlayer()
+		}
+	}
+	saveGame(alice, bot3)
+	saveGame(alice, bot3)
+	saveGame(bot3, alice)
+	saveGame(alice, bot3)
+	saveGame(bob, bot5)
+	saveGame(anonymous, bot5)
+
+	// a draw agreed before the board is full still counts
+	alice.SetToken('X')
+	bot5.SetToken('O')
+	drawn := connectfour.NewGame(alice, bot5)
+	for _, col := range []int{3, 3} {
+		player := drawn.CurrentPlayer()
+		if _, err = drawn.Play(player, col); err != nil {
+			t.Fatalf("failed to play move: %v", err)
+		}
+		if err = repo.SaveMove(ctx, drawn, player, col); err != nil {
+			t.Fatalf("failed to save move: %v", err)
+		}
+		drawn.NextPlayer()
+	}
+	if err = drawn.OfferDraw(alice); err != nil {
+		t.Fatalf("failed to offer draw: %v", err)
+	}
+	if err = drawn.AcceptDraw(bot5); err != nil {
+		t.Fatalf("failed to accept draw: %v", err)
+	}
+	if err = repo.SaveResult(ctx, drawn); err != nil {
+		t.Fatalf("failed to save result: %v", err)
+	}
+
+	tests := []struct {
+		view     string
+		expected []LeaderboardEntry
+	}{
+		{LeaderboardWins, []LeaderboardEntry{
+			{PlayerID: alice.ID(), Name: "Alice", Value: 3, Wins: 3, Draws: 1, Losses: 1},
+			{PlayerID: bob.ID(), Name: "Bob", Value: 1, Wins: 1},
+		}},
+		{LeaderboardStreak, []LeaderboardEntry{
+			{PlayerID: alice.ID(), Name: "Alice", Value: 2, Wins: 3, Draws: 1, Losses: 1},
+			{PlayerID: bob.ID(), Name: "Bob", Value: 1, Wins: 1},
+		}},
+		{LeaderboardVsBots, []LeaderboardEntry{
+			{PlayerID: alice.ID(), Name: "Alice", Value: 0.75, Wins: 3, Losses: 1, Difficulty: 3},
+			{PlayerID: bob.ID(), Name: "Bob", Value: 1, Wins: 1, Difficulty: 5},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.view, func(t *testing.T) {
+			entries, err := repo.Leaderboard(ctx, LeaderboardQuery{View: tt.view, Limit: 10})
+			if err != nil {
+				t.Fatalf("failed to get leaderboard: %v", err)
+			}
+			if len(entries) != len(tt.expected) {
+				t.Fatalf("expected %d entries, got %+v", len(tt.expected), entries)
+			}
+			for i, entry := range entries {
+				if entry != tt.expected[i] {
+					t.Errorf("entry %d: expected %+v, got %+v", i, tt.expected[i], entry)
+				}
+			}
+		})
+	}
+
+	scores, err := repo.Leaderboard(ctx, LeaderboardQuery{View: LeaderboardScore, Limit: 10})
+	if err != nil || len(scores) != 2 || scores[0].Value < scores[1].Value {
+		t.Errorf("expected scores sorted high to low, got %+v (%v)", scores, err)
+	}
+
+	recent, err := repo.Leaderboard(ctx, LeaderboardQuery{View: LeaderboardWins, Since: time.Now().Add(time.Hour), Limit: 10})
+	if err != nil || len(recent) != 0 {
+		t.Errorf("expected no games in the future window, got %+v (%v)", recent, err)
+	}
+}
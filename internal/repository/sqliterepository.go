@@ -0,0 +1,391 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
+	return &SQLiteRepository{db: db}
+}
+
+func (r *SQLiteRepository) SaveMove(ctx context.Context, game *connectfour.Game, player connectfour.Player, column int) (err error) {
+	defer observeSave(backendSQLite, "save_move", time.Now(), &err)
+	sqlCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	tx, err := r.db.BeginTx(sqlCtx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// mirror the mongo upsert: create the game on the first move and bump the move count on every move
+	var seriesID sql.NullString
+	var seriesGame sql.NullInt64
+	if inSeries(game) {
+		seriesID = sql.NullString{String: game.Series.ID, Valid: true}
+		seriesGame = sql.NullInt64{Int64: int64(game.Series.GameNumber()), Valid: true}
+	}
+	const upsertGame = `INSERT INTO games (id, variant, rows, cols, series_id, series_game, move_count, timestamp) VALUES (?, ?, ?, ?, ?, ?, 1, ?)
+		ON CONFLICT (id) DO UPDATE SET move_count = move_count + 1`
+	if _, err = tx.ExecContext(sqlCtx, upsertGame, game.ID, game.Variant, game.Board.NumRows(), game.Board.NumCols(),
+		seriesID, seriesGame, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	const upsertPlayer = `INSERT INTO players (game_id, seat, id, user_id, name, strategy, token, score,
+			bot_difficulty, bot_mistake_frequency, bot_randomize, bot_seed) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (game_id, seat) DO UPDATE SET
+			id = excluded.id, user_id = excluded.user_id, name = excluded.name, strategy = excluded.strategy,
+			token = excluded.token, score = excluded.score,
+			bot_difficulty = excluded.bot_difficulty, bot_mistake_frequency = excluded.bot_mistake_frequency,
+			bot_randomize = excluded.bot_randomize, bot_seed = excluded.bot_seed`
+	for seat := range game.Players {
+		mapped := mapPlayer(game, seat)
+		difficulty, mistakeFrequency, randomize, seed := botColumns(mapped.Bot)
+		userID := sql.NullString{String: mapped.UserID, Valid: mapped.UserID != ""}
+		if _, err = tx.ExecContext(sqlCtx, upsertPlayer, game.ID, seat+1, mapped.ID, userID, mapped.Name, mapped.Strategy, mapped.Token,
+			int64(mapped.Score), difficulty, mistakeFrequency, randomize, seed); err != nil {
+			return err
+		}
+	}
+
+	move := mapMove(game, player, column)
+	difficulty, mistakeFrequency, randomize, seed := botColumns(move.Bot)
+	const insertMove = `INSERT INTO moves (game_id, id, "column", player_id, think_time_ms, score_delta,
+			bot_difficulty, bot_mistake_frequency, bot_randomize, bot_seed) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if _, err = tx.ExecContext(sqlCtx, insertMove, game.ID, move.ID, move.Column, move.PlayerID, move.ThinkTime, int64(move.ScoreDelta),
+		difficulty, mistakeFrequency, randomize, seed); err != nil {
+		return err
+	}
+
+	if game.Winner != nil {
+		if _, err = tx.ExecContext(sqlCtx, "UPDATE games SET winner_id = ? WHERE id = ?", game.Winner.ID(), game.ID); err != nil {
+			return err
+		}
+	}
+	if game.Result != "" {
+		if _, err = tx.ExecContext(sqlCtx, "UPDATE games SET result = ? WHERE id = ?", game.Result, game.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) SaveResult(ctx context.Context, game *connectfour.Game) (err error) {
+	defer observeSave(backendSQLite, "save_result", time.Now(), &err)
+	sqlCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	var winnerID sql.NullString
+	if game.Winner != nil {
+		winnerID = sql.NullString{String: game.Winner.ID(), Valid: true}
+	}
+	resigned := resignedBy(game)
+	const updateResult = `UPDATE games SET result = ?, winner_id = COALESCE(?, winner_id), resigned_by = ? WHERE id = ?`
+	_, err = r.db.ExecContext(sqlCtx, updateResult, game.Result, winnerID, sql.NullString{String: resigned, Valid: resigned != ""}, game.ID)
+	return err
+}
+
+func (r *SQLiteRepository) GetGame(ctx context.Context, id string) (*Game, error) {
+	sqlCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	game := &Game{ID: id}
+	var winnerID, result, resigned, seriesID sql.NullString
+	var seriesGame sql.NullInt64
+	const selectGame = `SELECT variant, rows, cols, winner_id, result, resigned_by, series_id, series_game, move_count, timestamp
+		FROM games WHERE id = ?`
+	row := r.db.QueryRowContext(sqlCtx, selectGame, id)
+	if err := row.Scan(&game.Variant, &game.Rows, &game.Cols, &winnerID, &result, &resigned, &seriesID, &seriesGame,
+		&game.MoveCount, &game.Timestamp); errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGameNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	game.WinnerID, game.Result, game.ResignedBy = winnerID.String, result.String, resigned.String
+	game.SeriesID, game.SeriesGame = seriesID.String, int(seriesGame.Int64)
+
+	const selectPlayers = `SELECT seat, id, user_id, name, strategy, token, score,
+			bot_difficulty, bot_mistake_frequency, bot_randomize, bot_seed
+		FROM players WHERE game_id = ? ORDER BY seat`
+	players, err := r.db.QueryContext(sqlCtx, selectPlayers, id)
+	if err != nil {
+		return nil, err
+	}
+	defer players.Close()
+	for players.Next() {
+		var seat int
+		var player Player
+		var userID sql.NullString
+		var difficulty, mistakeFrequency, seed sql.NullInt64
+		var randomize sql.NullBool
+		if err = players.Scan(&seat, &player.ID, &userID, &player.Name, &player.Strategy, &player.Token, &player.Score,
+			&difficulty, &mistakeFrequency, &randomize, &seed); err != nil {
+			return nil, err
+		}
+		player.UserID = userID.String
+		player.Bot = scanBotConfig(difficulty, mistakeFrequency, randomize, seed)
+		if seat == 1 {
+			game.Player1 = player
+		} else {
+			game.Player2 = player
+		}
+	}
+	if err = players.Err(); err != nil {
+		return nil, err
+	}
+
+	const selectMoves = `SELECT id, "column", player_id, think_time_ms, score_delta,
+			bot_difficulty, bot_mistake_frequency, bot_randomize, bot_seed
+		FROM moves WHERE game_id = ? ORDER BY id`
+	moves, err := r.db.QueryContext(sqlCtx, selectMoves, id)
+	if err != nil {
+		return nil, err
+	}
+	defer moves.Close()
+	for moves.Next() {
+		var move Move
+		var difficulty, mistakeFrequency, seed sql.NullInt64
+		var randomize sql.NullBool
+		if err = moves.Scan(&move.ID, &move.Column, &move.PlayerID, &move.ThinkTime, &move.ScoreDelta,
+			&difficulty, &mistakeFrequency, &randomize, &seed); err != nil {
+			return nil, err
+		}
+		move.Bot = scanBotConfig(difficulty, mistakeFrequency, randomize, seed)
+		game.Moves = append(game.Moves, move)
+	}
+	return game, moves.Err()
+}
+
+// botColumns splits a bot config into its nullable columns, they're all null for humans.
+func botColumns(bot *BotConfig) (difficulty, mistakeFrequency sql.NullInt64, randomize sql.NullBool, seed sql.NullInt64) {
+	if bot == nil {
+		return
+	}
+	return sql.NullInt64{Int64: int64(bot.Difficulty), Valid: true},
+		sql.NullInt64{Int64: int64(bot.MistakeFrequency), Valid: true},
+		sql.NullBool{Bool: bot.Randomize, Valid: true},
+		sql.NullInt64{Int64: bot.Seed, Valid: true}
+}
+
+func scanBotConfig(difficulty, mistakeFrequency sql.NullInt64, randomize sql.NullBool, seed sql.NullInt64) *BotConfig {
+	if !difficulty.Valid {
+		return nil
+	}
+	return &BotConfig{
+		Difficulty:       int(difficulty.Int64),
+		MistakeFrequency: int(mistakeFrequency.Int64),
+		Randomize:        randomize.Bool,
+		Seed:             seed.Int64,
+	}
+}
+
+func (r *SQLiteRepository) SaveSeries(ctx context.Context, series *connectfour.Series) (err error) {
+	defer observeSave(backendSQLite, "save_series", time.Now(), &err)
+	sqlCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	tx, err := r.db.BeginTx(sqlCtx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	mapped := mapSeries(series)
+	const upsertSeries = `INSERT INTO series (id, best_of, player1_id, player2_id, winner_id, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET winner_id = excluded.winner_id, updated_at = excluded.updated_at`
+	winnerID := sql.NullString{String: mapped.WinnerID, Valid: mapped.WinnerID != ""}
+	if _, err = tx.ExecContext(sqlCtx, upsertSeries, mapped.ID, mapped.BestOf, mapped.Player1ID, mapped.Player2ID,
+		winnerID, mapped.UpdatedAt.UTC()); err != nil {
+		return err
+	}
+
+	const upsertGame = `INSERT INTO series_games (series_id, game_number, game_id, winner_id) VALUES (?, ?, ?, ?)
+		ON CONFLICT (series_id, game_number) DO NOTHING`
+	for i, game := range mapped.Games {
+		winnerID = sql.NullString{String: game.WinnerID, Valid: game.WinnerID != ""}
+		if _, err = tx.ExecContext(sqlCtx, upsertGame, mapped.ID, i+1, game.GameID, winnerID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) GetSeries(ctx context.Context, id string) (*Series, error) {
+	sqlCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	series := &Series{ID: id}
+	var winnerID sql.NullString
+	const selectSeries = `SELECT best_of, player1_id, player2_id, winner_id, updated_at FROM series WHERE id = ?`
+	err := r.db.QueryRowContext(sqlCtx, selectSeries, id).
+		Scan(&series.BestOf, &series.Player1ID, &series.Player2ID, &winnerID, &series.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSeriesNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	series.WinnerID = winnerID.String
+
+	rows, err := r.db.QueryContext(sqlCtx, "SELECT game_id, winner_id FROM series_games WHERE series_id = ? ORDER BY game_number", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var game SeriesGame
+		var gameWinnerID sql.NullString
+		if err = rows.Scan(&game.GameID, &gameWinnerID); err != nil {
+			return nil, err
+		}
+		game.WinnerID = gameWinnerID.String
+		series.Games = append(series.Games, game)
+	}
+	return series, rows.Err()
+}
+
+func (r *SQLiteRepository) GetRating(ctx context.Context, subjectID string) (*Rating, error) {
+	sqlCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	rating := &Rating{SubjectID: subjectID}
+	const selectRating = `SELECT kind, name, rating, deviation, volatility, games, updated_at FROM ratings WHERE subject_id = ?`
+	err := r.db.QueryRowContext(sqlCtx, selectRating, subjectID).
+		Scan(&rating.Kind, &rating.Name, &rating.Rating, &rating.Deviation, &rating.Volatility, &rating.Games, &rating.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRatingNotFound
+	}
+	return rating, err
+}
+
+func (r *SQLiteRepository) SaveRating(ctx context.Context, rating *Rating, history RatingHistory) (err error) {
+	defer observeSave(backendSQLite, "save_rating", time.Now(), &err)
+	sqlCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	tx, err := r.db.BeginTx(sqlCtx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// the games count is the rating's version, it's only replaced if nothing was rated since the read
+	const upsertRating = `INSERT INTO ratings (subject_id, kind, name, rating, deviation, volatility, games, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (subject_id) DO UPDATE SET
+			kind = excluded.kind, name = excluded.name, rating = excluded.rating, deviation = excluded.deviation,
+			volatility = excluded.volatility, games = excluded.games, updated_at = excluded.updated_at
+		WHERE ratings.games = excluded.games - 1`
+	result, err := tx.ExecContext(sqlCtx, upsertRating, rating.SubjectID, rating.Kind, rating.Name, rating.Rating,
+		rating.Deviation, rating.Volatility, rating.Games, rating.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if saved, err := result.RowsAffected(); err != nil {
+		return err
+	} else if saved == 0 {
+		return ErrRatingConflict
+	}
+
+	const insertHistory = `INSERT INTO rating_history (subject_id, game_id, before, after, deviation, volatility, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	if _, err = tx.ExecContext(sqlCtx, insertHistory, history.SubjectID, history.GameID, history.Before, history.After,
+		history.Deviation, history.Volatility, history.Timestamp); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) CreateUser(ctx context.Context, user *User) (err error) {
+	defer observeSave(backendSQLite, "create_user", time.Now(), &err)
+	sqlCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	const insertUser = `INSERT INTO users (id, username, password_hash, display_name, color, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err = r.db.ExecContext(sqlCtx, insertUser, user.ID, user.Username, user.PasswordHash, user.DisplayName, user.Color, user.CreatedAt)
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return ErrUsernameTaken
+	}
+	return err
+}
+
+func (r *SQLiteRepository) GetUser(ctx context.Context, id string) (*User, error) {
+	return r.findUser(ctx, "id", id)
+}
+
+func (r *SQLiteRepository) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	return r.findUser(ctx, "username", username)
+}
+
+func (r *SQLiteRepository) findUser(ctx context.Context, column, value string) (*User, error) {
+	sqlCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	user := new(User)
+	selectUser := "SELECT id, username, password_hash, display_name, color, created_at FROM users WHERE " + column + " = ?"
+	err := r.db.QueryRowContext(sqlCtx, selectUser, value).
+		Scan(&user.ID, &user.Username, &user.PasswordHash, &user.DisplayName, &user.Color, &user.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *SQLiteRepository) UpdateUser(ctx context.Context, user *User) (err error) {
+	defer observeSave(backendSQLite, "update_user", time.Now(), &err)
+	sqlCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	const updateUser = `UPDATE users SET password_hash = ?, display_name = ?, color = ? WHERE id = ?`
+	result, err := r.db.ExecContext(sqlCtx, updateUser, user.PasswordHash, user.DisplayName, user.Color, user.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) SaveSession(ctx context.Context, session *SuspendedSession) (err error) {
+	defer observeSave(backendSQLite, "save_session", time.Now(), &err)
+	sqlCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	const upsertSession = `INSERT INTO suspended_sessions (id, game_id, suspended_at) VALUES (?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET game_id = excluded.game_id, suspended_at = excluded.suspended_at`
+	_, err = r.db.ExecContext(sqlCtx, upsertSession, session.ID, session.GameID, session.SuspendedAt)
+	return err
+}
+
+func (r *SQLiteRepository) TakeSession(ctx context.Context, id string) (*SuspendedSession, error) {
+	sqlCtx, ctxCancel := context.WithTimeout(ctx, time.Second)
+	defer ctxCancel()
+
+	session := &SuspendedSession{ID: id}
+	const deleteSession = `DELETE FROM suspended_sessions WHERE id = ? RETURNING game_id, suspended_at`
+	err := r.db.QueryRowContext(sqlCtx, deleteSession, id).Scan(&session.GameID, &session.SuspendedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	return session, err
+}
+
+func (r *SQLiteRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/sqlite"
+)
+
+func TestSQLiteRepository_SaveMove(t *testing.T) {
+	provider, err := sqlite.NewProvider(&sqlite.Config{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Close()
+	repo := NewSQLiteRepository(provider.DB())
+
+	player1 := connectfour.NewHumanPlayer("Player1", 'X')
+	player2 := connectfour.NewMinimaxBot('O')
+	player2.Config.SetDifficulty(3).SetMistakeFrequency(0)
+	startSeed := player2.Config.Seed
+	game := connectfour.NewGame(player1, player2)
+
+	// play a vertical four for player 1
+	ctx := context.Background()
+	for i := 0; i < 7 && game.InProgress(); i++ {
+		player := game.CurrentPlayer()
+		col := 0
+		if player == connectfour.Player(player2) {
+			col = 1
+		}
+		if _, err = game.Play(player, col); err != nil {
+			t.Fatalf("failed to play move %d: %v", i, err)
+		}
+		if err = repo.SaveMove(ctx, game, player, col); err != nil {
+			t.Fatalf("failed to save move %d: %v", i, err)
+		}
+		game.NextPlayer()
+		if i == 1 {
+			// reconfigured after its first move, as /bot/config would
+			player2.Config.SetDifficulty(5).SetSeed(42)
+		}
+	}
+
+	stored, err := repo.GetGame(ctx, game.ID)
+	if err != nil {
+		t.Fatalf("failed to get game: %v", err)
+	}
+	if stored.MoveCount != 7 || len(stored.Moves) != 7 {
+		t.Errorf("expected 7 moves, got move_count=%d moves=%d", stored.MoveCount, len(stored.Moves))
+	}
+	if stored.Player1.ID != player1.ID() || stored.Player2.ID != player2.ID() {
+		t.Errorf("players not stored in seat order: %+v %+v", stored.Player1, stored.Player2)
+	}
+	if stored.WinnerID != player1.ID() || stored.Result != string(connectfour.ResultConnectFour) {
+		t.Errorf("expected winner %s by connect four, got %s by %q", player1.ID(), stored.WinnerID, stored.Result)
+	}
+	for i, move := range stored.Moves {
+		if move.ID != i+1 {
+			t.Errorf("expected move %d to have id %d, got %d", i, i+1, move.ID)
+		}
+		if changed := move.Bot != nil; changed != (i == 3) {
+			t.Errorf("expected only the bot's second move to record a config change, move %d has %+v", i, move.Bot)
+		}
+	}
+	if bot := stored.Player2.Bot; bot == nil || bot.Difficulty != 3 || bot.Seed != startSeed {
+		t.Errorf("expected the bot's starting config to be stored, got %+v", bot)
+	}
+	if bot := stored.Moves[3].Bot; bot == nil || bot.Difficulty != 5 || bot.Seed != 42 {
+		t.Errorf("expected the changed config on the bot's second move, got %+v", bot)
+	}
+
+	replayed, err := connectfour.Replay(stored.Setup(), stored.ReplayMoves())
+	if err != nil {
+		t.Fatalf("failed to replay stored game: %v", err)
+	}
+	if replayed.State != connectfour.GameStateWin || replayed.Winner.ID() != player1.ID() {
+		t.Errorf("expected replayed game to be won by player 1, got state %d", replayed.State)
+	}
+	if bot, ok := replayed.Players[1].(*connectfour.BotPlayer); !ok || bot.Config.Difficulty != 5 || bot.Config.Seed != 42 {
+		t.Errorf("expected the bot's latest config to be restored, got %+v", replayed.Players[1])
+	}
+
+	if _, err = repo.GetGame(ctx, "missing"); !errors.Is(err, ErrGameNotFound) {
+		t.Errorf("expected ErrGameNotFound, got %v", err)
+	}
+}
+
+func TestSQLiteRepository_SaveResult(t *testing.T) {
+	provider, err := sqlite.NewProvider(&sqlite.Config{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Close()
+	repo := NewSQLiteRepository(provider.DB())
+
+	player1, player2 := connectfour.NewHumanPlayerPair()
+	game := connectfour.NewGame(player1, player2)
+	ctx := context.Background()
+	if _, err = game.Play(player1, 3); err != nil {
+		t.Fatalf("failed to play: %v", err)
+	}
+	if err = repo.SaveMove(ctx, game, player1, 3); err != nil {
+		t.Fatalf("failed to save move: %v", err)
+	}
+	game.NextPlayer()
+
+	if err = game.Resign(player2); err != nil {
+		t.Fatalf("failed to resign: %v", err)
+	}
+	if err = repo.SaveResult(ctx, game); err != nil {
+		t.Fatalf("failed to save result: %v", err)
+	}
+
+	stored, err := repo.GetGame(ctx, game.ID)
+	if err != nil {
+		t.Fatalf("failed to get game: %v", err)
+	}
+	if stored.Result != string(connectfour.ResultResignation) || stored.ResignedBy != player2.ID() || stored.WinnerID != player1.ID() {
+		t.Errorf("expected player 2 to have resigned, got result %q resigned_by %q winner %q", stored.Result, stored.ResignedBy, stored.WinnerID)
+	}
+}
+
+func TestSQLiteRepository_SaveSeries(t *testing.T) {
+	provider, err := sqlite.NewProvider(&sqlite.Config{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Close()
+	repo := NewSQLiteRepository(provider.DB())
+
+	player1, player2 := connectfour.NewHumanPlayerPair()
+	game := connectfour.NewGame(player1, player2)
+	if err = game.SetBestOf(3); err != nil {
+		t.Fatalf("failed to set series length: %v", err)
+	}
+	series := game.Series
+
+	// player 2 wins the first game, player 1 the second
+	ctx := context.Background()
+	for _, loser := range []connectfour.Player{player1, player2} {
+		if err = game.Resign(loser); err != nil {
+			t.Fatalf("failed to resign: %v", err)
+		}
+		series.Record(game)
+		if err = repo.SaveSeries(ctx, series); err != nil {
+			t.Fatalf("failed to save series: %v", err)
+		}
+		if err = game.Rematch(); err != nil {
+			t.Fatalf("failed to start rematch: %v", err)
+		}
+	}
+
+	stored, err := repo.GetSeries(ctx, series.ID)
+	if err != nil {
+		t.Fatalf("failed to get series: %v", err)
+	}
+	if stored.BestOf != 3 || stored.Player1ID != player1.ID() || stored.Player2ID != player2.ID() || stored.WinnerID != "" {
+		t.Errorf("unexpected series %+v", stored)
+	}
+	if len(stored.Games) != 2 || stored.Games[0].WinnerID != player2.ID() || stored.Games[1].WinnerID != player1.ID() {
+		t.Errorf("expected games won by player 2 then player 1, got %+v", stored.Games)
+	}
+
+	if _, err = repo.GetSeries(ctx, "missing"); !errors.Is(err, ErrSeriesNotFound) {
+		t.Errorf("expected ErrSeriesNotFound, got %v", err)
+	}
+}
+
+func TestSQLiteRepository_SaveRating(t *testing.T) {
+	provider, err := sqlite.NewProvider(&sqlite.Config{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Close()
+	repo := NewSQLiteRepository(provider.DB())
+
+	ctx := context.Background()
+	if _, err = repo.GetRating(ctx, "player:1"); !errors.Is(err, ErrRatingNotFound) {
+		t.Fatalf("expected ErrRatingNotFound, got %v", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, game := range []string{"game1", "game2"} {
+		rating := &Rating{SubjectID: "player:1", Kind: "human", Name: "Player1", Rating: 1500 + float64(i*10),
+			Deviation: 300, Volatility: 0.06, Games: i + 1, UpdatedAt: now}
+		history := RatingHistory{SubjectID: "player:1", GameID: game, Before: 1500, After: rating.Rating, Timestamp: now}
+		if err = repo.SaveRating(ctx, rating, history); err != nil {
+			t.Fatalf("failed to save rating: %v", err)
+		}
+	}
+
+	rating, err := repo.GetRating(ctx, "player:1")
+	if err != nil {
+		t.Fatalf("failed to get rating: %v", err)
+	}
+	if rating.Rating != 1510 || rating.Games != 2 || !rating.UpdatedAt.Equal(now) {
+		t.Errorf("unexpected rating: %+v", rating)
+	}
+
+	// a rating updated from a read before the last game was saved is turned away
+	stale := &Rating{SubjectID: "player:1", Kind: "human", Name: "Player1", Rating: 1490, Games: 2, UpdatedAt: now}
+	history := RatingHistory{SubjectID: "player:1", GameID: "game3", Before: 1500, After: 1490, Timestamp: now}
+	if err = repo.SaveRating(ctx, stale, history); !errors.Is(err, ErrRatingConflict) {
+		t.Errorf("expected ErrRatingConflict, got %v", err)
+	}
+	if rating, err = repo.GetRating(ctx, "player:1"); err != nil || rating.Rating != 1510 {
+		t.Errorf("expected the rating to be left alone, got %+v %v", rating, err)
+	}
+}
+
+func TestSQLiteRepository_Users(t *testing.T) {
+	provider, err := sqlite.NewProvider(&sqlite.Config{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Close()
+	repo := NewSQLiteRepository(provider.DB())
+	ctx := context.Background()
+
+	user := &User{ID: "1", Username: "Alice", PasswordHash: "hash", DisplayName: "Alice", Color: "red", CreatedAt: time.Now()}
+	if err = repo.CreateUser(ctx, user); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if err = repo.CreateUser(ctx, &User{ID: "2", Username: "alice", CreatedAt: time.Now()}); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("expected ErrUsernameTaken, got %v", err)
+	}
+
+	user.DisplayName, user.Color = "Al", "yellow"
+	if err = repo.UpdateUser(ctx, user); err != nil {
+		t.Fatalf("failed to update user: %v", err)
+	}
+	stored, err := repo.GetUserByUsername(ctx, "ALICE")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	if stored.ID != "1" || stored.DisplayName != "Al" || stored.Color != "yellow" {
+		t.Errorf("unexpected user: %+v", stored)
+	}
+	if _, err = repo.GetUser(ctx, "2"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestSQLiteRepository_TakeSession(t *testing.T) {
+	provider, err := sqlite.NewProvider(&sqlite.Config{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Close()
+	repo := NewSQLiteRepository(provider.DB())
+
+	ctx := context.Background()
+	for _, gameID := range []string{"game-1", "game-2"} {
+		if err = repo.SaveSession(ctx, &SuspendedSession{ID: "session", GameID: gameID, SuspendedAt: time.Now()}); err != nil {
+			t.Fatalf("failed to save session: %v", err)
+		}
+	}
+
+	session, err := repo.TakeSession(ctx, "session")
+	if err != nil {
+		t.Fatalf("failed to take session: %v", err)
+	}
+	if session.GameID != "game-2" {
+		t.Errorf("expected the latest game to be kept, got %s", session.GameID)
+	}
+	if _, err = repo.TakeSession(ctx, "session"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected a session to only be taken once, got %v", err)
+	}
+}
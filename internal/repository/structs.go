@@ -2,27 +2,212 @@ package repository
 
 import (
 	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
 )
 
+type BotConfig struct {
+	Difficulty       int   `bson:"difficulty"`
+	MistakeFrequency int   `bson:"mistake_frequency"`
+	Randomize        bool  `bson:"randomize"`
+	Seed             int64 `bson:"seed"`
+}
+
 type Player struct {
-	ID       string `bson:"id"`
-	Strategy string `bson:"strategy"`
-	Score    uint64 `bson:"score"`
-	Token    rune   `bson:"token"`
+	ID       string     `bson:"id"`
+	UserID   string     `bson:"user_id,omitempty"`
+	Name     string     `bson:"name"`
+	Strategy string     `bson:"strategy"`
+	Score    uint64     `bson:"score"`
+	Token    rune       `bson:"token"`
+	Bot      *BotConfig `bson:"bot,omitempty"`
 }
 
 type Move struct {
-	ID       int    `bson:"id"`
-	Column   int    `bson:"column"`
-	PlayerID string `bson:"player_id"`
+	ID         int        `bson:"id"`
+	Column     int        `bson:"column"`
+	PlayerID   string     `bson:"player_id"`
+	ThinkTime  int64      `bson:"think_time_ms"`
+	ScoreDelta uint64     `bson:"score_delta"`
+	Bot        *BotConfig `bson:"bot,omitempty"` // the bot's new config, if it changed before this move
 }
 
 type Game struct {
-	ID        string    `bson:"_id,omitempty"`
-	Player1   Player    `bson:"player1"`
-	Player2   Player    `bson:"player2"`
-	Moves     []Move    `bson:"moves"`
-	Winner    *Player   `bson:"winner,omitempty"`
-	MoveCount int       `bson:"move_count"`
-	Timestamp time.Time `bson:"timestamp"`
+	ID         string    `bson:"_id,omitempty"`
+	Variant    string    `bson:"variant"`
+	Rows       int       `bson:"rows"`
+	Cols       int       `bson:"cols"`
+	Player1    Player    `bson:"player1"`
+	Player2    Player    `bson:"player2"`
+	Moves      []Move    `bson:"moves"`
+	WinnerID   string    `bson:"winner,omitempty"`
+	Result     string    `bson:"result,omitempty"`
+	ResignedBy string    `bson:"resigned_by,omitempty"`
+	SeriesID   string    `bson:"series_id,omitempty"`
+	SeriesGame int       `bson:"series_game,omitempty"`
+	MoveCount  int       `bson:"move_count"`
+	Timestamp  time.Time `bson:"timestamp"`
+}
+
+// Setup converts the stored game into the setup connectfour.Replay needs to rebuild it.
+func (g *Game) Setup() connectfour.GameSetup {
+	setup := connectfour.GameSetup{ID: g.ID, Variant: g.Variant, Rows: g.Rows, Cols: g.Cols}
+	for i, p := range []Player{g.Player1, g.Player2} {
+		setup.Players[i] = connectfour.PlayerSetup{ID: p.ID, UserID: p.UserID, Name: p.Name, Token: p.Token, Strategy: p.Strategy}
+		setup.Players[i].Config = p.Bot.config()
+	}
+	return setup
+}
+
+// ReplayMoves converts the stored moves into the moves connectfour.Replay expects.
+func (g *Game) ReplayMoves() []connectfour.Move {
+	moves := make([]connectfour.Move, len(g.Moves))
+	for i, m := range g.Moves {
+		moves[i] = connectfour.Move{
+			Column:     m.Column,
+			PlayerID:   m.PlayerID,
+			ThinkTime:  time.Duration(m.ThinkTime) * time.Millisecond,
+			ScoreDelta: m.ScoreDelta,
+			Config:     m.Bot.config(),
+		}
+	}
+	return moves
+}
+
+// Series is a best-of-N match, games are in the order they were played.
+type Series struct {
+	ID        string       `bson:"_id"`
+	BestOf    int          `bson:"best_of"`
+	Player1ID string       `bson:"player1_id"`
+	Player2ID string       `bson:"player2_id"`
+	Games     []SeriesGame `bson:"games"`
+	WinnerID  string       `bson:"winner,omitempty"`
+	UpdatedAt time.Time    `bson:"updated_at"`
+}
+
+type SeriesGame struct {
+	GameID   string `bson:"game_id"`
+	WinnerID string `bson:"winner,omitempty"`
+}
+
+// inSeries reports whether the game is part of a series worth saving, single games aren't.
+func inSeries(game *connectfour.Game) bool {
+	return game.Series != nil && game.Series.BestOf > 1
+}
+
+func mapSeries(series *connectfour.Series) *Series {
+	mapped := &Series{
+		ID:        series.ID,
+		BestOf:    series.BestOf,
+		Player1ID: series.PlayerIDs[0],
+		Player2ID: series.PlayerIDs[1],
+		Games:     make([]SeriesGame, len(series.Results)),
+		WinnerID:  series.WinnerID(),
+		UpdatedAt: time.Now(),
+	}
+	for i, result := range series.Results {
+		mapped.Games[i] = SeriesGame{GameID: result.GameID, WinnerID: result.WinnerID}
+	}
+	return mapped
+}
+
+// resignedBy is the ID of the player who resigned, if anyone did.
+func resignedBy(game *connectfour.Game) string {
+	if game.ResignedBy == nil {
+		return ""
+	}
+	return game.ResignedBy.ID()
+}
+
+func mapBotConfig(config *connectfour.Config) *BotConfig {
+	if config == nil {
+		return nil
+	}
+	return &BotConfig{
+		Difficulty:       config.Difficulty,
+		MistakeFrequency: config.MistakeFrequency,
+		Randomize:        config.Randomize,
+		Seed:             config.Seed,
+	}
+}
+
+func (c *BotConfig) config() *connectfour.Config {
+	if c == nil {
+		return nil
+	}
+	return &connectfour.Config{
+		Difficulty:       c.Difficulty,
+		MistakeFrequency: c.MistakeFrequency,
+		Randomize:        c.Randomize,
+		Seed:             c.Seed,
+	}
+}
+
+// mapPlayer maps the player in the given seat, bots are saved with the config they started the
+// game with.
+func mapPlayer(game *connectfour.Game, seat int) Player {
+	player := game.Players[seat]
+	mapped := Player{
+		ID:       player.ID(),
+		Name:     player.Name(),
+		Strategy: player.Strategy(),
+		Token:    player.Token(),
+		Score:    player.Score(),
+		Bot:      mapBotConfig(game.Setup().Players[seat].Config),
+	}
+	if human, ok := player.(*connectfour.HumanPlayer); ok {
+		mapped.UserID = human.UserID()
+	}
+	return mapped
+}
+
+func mapMove(game *connectfour.Game, player connectfour.Player, column int) Move {
+	move := Move{
+		ID:       game.MoveCount,
+		Column:   column,
+		PlayerID: player.ID(),
+	}
+	if last, ok := game.LastMove(); ok {
+		move.ThinkTime = last.ThinkTime.Milliseconds()
+		move.ScoreDelta = last.ScoreDelta
+		move.Bot = mapBotConfig(last.Config)
+	}
+	return move
+}
+
+type Rating struct {
+	SubjectID  string    `bson:"_id"`
+	Kind       string    `bson:"kind"`
+	Name       string    `bson:"name"`
+	Rating     float64   `bson:"rating"`
+	Deviation  float64   `bson:"deviation"`
+	Volatility float64   `bson:"volatility"`
+	Games      int       `bson:"games"`
+	UpdatedAt  time.Time `bson:"updated_at"`
+}
+
+type RatingHistory struct {
+	SubjectID  string    `bson:"subject_id"`
+	GameID     string    `bson:"game_id"`
+	Before     float64   `bson:"before"`
+	After      float64   `bson:"after"`
+	Deviation  float64   `bson:"deviation"`
+	Volatility float64   `bson:"volatility"`
+	Timestamp  time.Time `bson:"timestamp"`
+}
+
+type User struct {
+	ID           string    `bson:"_id"`
+	Username     string    `bson:"username"`
+	PasswordHash string    `bson:"password_hash"`
+	DisplayName  string    `bson:"display_name"`
+	Color        string    `bson:"color"`
+	CreatedAt    time.Time `bson:"created_at"`
+}
+
+// SuspendedSession links a browser session to the game it was playing when the server shut down.
+type SuspendedSession struct {
+	ID          string    `bson:"_id"`
+	GameID      string    `bson:"game_id"`
+	SuspendedAt time.Time `bson:"suspended_at"`
 }
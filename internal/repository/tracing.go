@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracedRepository wraps a repository with a span for every call.
+type TracedRepository struct {
+	repo    Repository
+	backend string
+}
+
+func NewTracedRepository(repo Repository, backend string) *TracedRepository {
+	return &TracedRepository{repo: repo, backend: backend}
+}
+
+func (r *TracedRepository) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.system", r.backend))
+	return tracing.Tracer().Start(ctx, "Repository."+name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func (r *TracedRepository) SaveMove(ctx context.Context, game *connectfour.Game, player connectfour.Player, column int) (err error) {
+	ctx, span := r.start(ctx, "SaveMove", attribute.String("game.id", game.ID))
+	defer tracing.End(span, &err)
+	return r.repo.SaveMove(ctx, game, player, column)
+}
+
+func (r *TracedRepository) SaveResult(ctx context.Context, game *connectfour.Game) (err error) {
+	ctx, span := r.start(ctx, "SaveResult", attribute.String("game.id", game.ID))
+	defer tracing.End(span, &err)
+	return r.repo.SaveResult(ctx, game)
+}
+
+func (r *TracedRepository) GetGame(ctx context.Context, id string) (result *Game, err error) {
+	ctx, span := r.start(ctx, "GetGame", attribute.String("game.id", id))
+	defer tracing.End(span, &err)
+	return r.repo.GetGame(ctx, id)
+}
+
+func (r *TracedRepository) SaveSeries(ctx context.Context, series *connectfour.Series) (err error) {
+	ctx, span := r.start(ctx, "SaveSeries", attribute.String("series.id", series.ID))
+	defer tracing.End(span, &err)
+	return r.repo.SaveSeries(ctx, series)
+}
+
+func (r *TracedRepository) GetSeries(ctx context.Context, id string) (result *Series, err error) {
+	ctx, span := r.start(ctx, "GetSeries", attribute.String("series.id", id))
+	defer tracing.End(span, &err)
+	return r.repo.GetSeries(ctx, id)
+}
+
+func (r *TracedRepository) GetRating(ctx context.Context, subjectID string) (result *Rating, err error) {
+	ctx, span := r.start(ctx, "GetRating", attribute.String("rating.subject_id", subjectID))
+	defer tracing.End(span, &err)
+	return r.repo.GetRating(ctx, subjectID)
+}
+
+func (r *TracedRepository) SaveRating(ctx context.Context, rating *Rating, history RatingHistory) (err error) {
+	ctx, span := r.start(ctx, "SaveRating", attribute.String("rating.subject_id", rating.SubjectID))
+	defer tracing.End(span, &err)
+	return r.repo.SaveRating(ctx, rating, history)
+}
+
+func (r *TracedRepository) Leaderboard(ctx context.Context, query LeaderboardQuery) (result []LeaderboardEntry, err error) {
+	ctx, span := r.start(ctx, "Leaderboard")
+	defer tracing.End(span, &err)
+	return r.repo.Leaderboard(ctx, query)
+}
+
+func (r *TracedRepository) CreateUser(ctx context.Context, user *User) (err error) {
+	ctx, span := r.start(ctx, "CreateUser", attribute.String("user.id", user.ID))
+	defer tracing.End(span, &err)
+	return r.repo.CreateUser(ctx, user)
+}
+
+func (r *TracedRepository) GetUser(ctx context.Context, id string) (result *User, err error) {
+	ctx, span := r.start(ctx, "GetUser", attribute.String("user.id", id))
+	defer tracing.End(span, &err)
+	return r.repo.GetUser(ctx, id)
+}
+
+func (r *TracedRepository) GetUserByUsername(ctx context.Context, username string) (result *User, err error) {
+	ctx, span := r.start(ctx, "GetUserByUsername")
+	defer tracing.End(span, &err)
+	return r.repo.GetUserByUsername(ctx, username)
+}
+
+func (r *TracedRepository) UpdateUser(ctx context.Context, user *User) (err error) {
+	ctx, span := r.start(ctx, "UpdateUser", attribute.String("user.id", user.ID))
+	defer tracing.End(span, &err)
+	return r.repo.UpdateUser(ctx, user)
+}
+
+func (r *TracedRepository) SaveSession(ctx context.Context, session *SuspendedSession) (err error) {
+	ctx, span := r.start(ctx, "SaveSession", attribute.String("session.id", session.ID), attribute.String("game.id", session.GameID))
+	defer tracing.End(span, &err)
+	return r.repo.SaveSession(ctx, session)
+}
+
+func (r *TracedRepository) TakeSession(ctx context.Context, id string) (result *SuspendedSession, err error) {
+	ctx, span := r.start(ctx, "TakeSession", attribute.String("session.id", id))
+	defer tracing.End(span, &err)
+	return r.repo.TakeSession(ctx, id)
+}
+
+// Ping isn't traced, readiness probes would drown out everything else.
+func (r *TracedRepository) Ping(ctx context.Context) error {
+	return r.repo.Ping(ctx)
+}
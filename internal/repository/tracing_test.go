@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracedRepository(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	repo := NewTracedRepository(NewMockRepository(), "mock")
+	if _, err := repo.GetGame(context.Background(), "missing"); !errors.Is(err, ErrGameNotFound) {
+		t.Fatalf("expected the wrapped repository's error, got %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "Repository.GetGame" || span.Status().Code != codes.Error {
+		t.Errorf("expected a failed Repository.GetGame span, got %s with status %v", span.Name(), span.Status())
+	}
+	attrs := make(map[string]string)
+	for _, attr := range span.Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs["db.system"] != "mock" || attrs["game.id"] != "missing" {
+		t.Errorf("unexpected attributes: %v", attrs)
+	}
+}
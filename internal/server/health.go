@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+const readinessTimeout = 2 * time.Second
+
+// registerProbes registers the health probes and the metrics endpoint, they're polled by
+// infrastructure rather than visited by players.
+func (s *Server) registerProbes(r gin.IRoutes) {
+	r.GET("/healthz", s.healthz)
+	r.GET("/readyz", s.readyz)
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+}
+
+// healthz reports the process is up, it doesn't check any dependencies.
+func (s *Server) healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readyz reports whether the server should get traffic. It stops being ready as soon as a
+// shutdown starts and whenever the repository can't be reached.
+func (s *Server) readyz(c *gin.Context) {
+	if s.shuttingDown.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting down"})
+		return
+	}
+
+	ctx, ctxCancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer ctxCancel()
+	if err := s.repo.Ping(ctx); err != nil {
+		slog.Warn("Readiness check failed", "error", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "error": "repository unreachable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
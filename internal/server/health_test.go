@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Zach51920/connect-four/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestServer_RegisterProbes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, err := newRouter(&config.ServerConfig{})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	s := &Server{}
+	s.registerProbes(r.Group(""))
+
+	// stands in for the session and log middleware added after the probes
+	r.Use(func(c *gin.Context) { c.Header("X-Session", "created") })
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		path        string
+		wantSession bool
+	}{
+		{"/healthz", false},
+		{"/metrics", false},
+		{"/", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, httptest.NewRequest("GET", tt.path, nil))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", recorder.Code)
+			}
+			if got := recorder.Header().Get("X-Session") != ""; got != tt.wantSession {
+				t.Errorf("expected the middleware to run: %t, ran: %t", tt.wantSession, got)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package server
 
 import (
+	"github.com/Zach51920/connect-four/internal/handlers"
+	"github.com/Zach51920/connect-four/internal/metrics"
+	"github.com/Zach51920/connect-four/internal/ratelimit"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -23,6 +26,33 @@ func sessionMiddleware(c *gin.Context) {
 	c.Next()
 }
 
+// userMiddleware exposes the signed in user, if any, to the handlers.
+func userMiddleware(c *gin.Context) {
+	s := sessions.Default(c)
+	if userID, ok := s.Get("user_id").(string); ok && userID != "" {
+		c.Set("user_id", userID)
+	}
+	c.Next()
+}
+
+// rateLimitMiddleware turns away clients that have used up their IP's or session's requests.
+// Clearing cookies gets a new session, so the IP limit is checked first and set higher.
+func rateLimitMiddleware(sessionLimiter, ipLimiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ipLimiter.Allow(c.ClientIP()) {
+			slog.Debug("Rate limited client IP", "ip", c.ClientIP())
+			handlers.TooManyRequests(c, metrics.ScopeIP, "Too many requests, slow down")
+			return
+		}
+		if !sessionLimiter.Allow(c.GetString("session_id")) {
+			slog.Debug("Rate limited session", "session_id", c.GetString("session_id"))
+			handlers.TooManyRequests(c, metrics.ScopeSession, "Too many requests, slow down")
+			return
+		}
+		c.Next()
+	}
+}
+
 func setSessionID(s sessions.Session) string {
 	sessionID := uuid.New().String()
 	slog.Debug("assigning session ID: not found in session", "session_id", sessionID)
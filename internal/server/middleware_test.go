@@ -0,0 +1,49 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Zach51920/connect-four/internal/config"
+	"github.com/Zach51920/connect-four/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitMiddleware_SpoofedIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		proxies    []string
+		wantStatus int
+	}{
+		{"no trusted proxies", nil, http.StatusTooManyRequests},
+		{"trusted proxy", []string{"10.0.0.1"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newRouter(&config.ServerConfig{TrustedProxies: tt.proxies})
+			if err != nil {
+				t.Fatalf("failed to create router: %v", err)
+			}
+			limit := rateLimitMiddleware(ratelimit.NewLimiter(ratelimit.Config{Rate: 100, Burst: 100}), ratelimit.NewLimiter(ratelimit.Config{Rate: 0.001, Burst: 1}))
+			r.POST("/game", limit, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			// every request claims another IP, as a client without cookies would
+			var status int
+			for i := range 2 {
+				req := httptest.NewRequest("POST", "/game", nil)
+				req.RemoteAddr = "10.0.0.1:1234"
+				req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i+1))
+				recorder := httptest.NewRecorder()
+				r.ServeHTTP(recorder, req)
+				status = recorder.Code
+			}
+			if status != tt.wantStatus {
+				t.Errorf("expected the second request to get %d, got %d", tt.wantStatus, status)
+			}
+		})
+	}
+}
@@ -1,26 +1,60 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/Zach51920/connect-four/internal/config"
+	"github.com/Zach51920/connect-four/internal/connectfour"
 	"github.com/Zach51920/connect-four/internal/handlers"
+	"github.com/Zach51920/connect-four/internal/matchmaking"
+	"github.com/Zach51920/connect-four/internal/metrics"
+	"github.com/Zach51920/connect-four/internal/models"
 	"github.com/Zach51920/connect-four/internal/mongo"
+	"github.com/Zach51920/connect-four/internal/ratelimit"
 	"github.com/Zach51920/connect-four/internal/repository"
 	"github.com/Zach51920/connect-four/internal/services"
+	gamesessions "github.com/Zach51920/connect-four/internal/sessions"
+	"github.com/Zach51920/connect-four/internal/sqlite"
+	"github.com/Zach51920/connect-four/internal/tracing"
+	"github.com/Zach51920/connect-four/internal/webhook"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"log"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultReadTimeout     = 15 * time.Second
+	defaultIdleTimeout     = 60 * time.Second
+	defaultShutdownTimeout = 30 * time.Second
+	suspendTimeout         = 5 * time.Second
 )
 
 type Server struct {
-	router   *gin.Engine
-	config   *config.ServerConfig
-	provider *mongo.Provider
+	router          *gin.Engine
+	httpServer      *http.Server
+	config          *config.ServerConfig
+	provider        *mongo.Provider
+	sqliteProvider  *sqlite.Provider
+	shutdownTracing func(context.Context) error
+
+	repo         repository.Repository
+	store        *gamesessions.MemorySessionStore
+	service      *services.GameService
+	matchmaker   *services.MatchmakingService
+	bots         *services.BotRunner
+	shuttingDown atomic.Bool
 }
 
 func New(cfg *config.ServerConfig) *Server {
@@ -28,28 +62,51 @@ func New(cfg *config.ServerConfig) *Server {
 }
 
 func (s *Server) init() error {
+	// start tracing before anything that might be traced
+	shutdownTracing, err := tracing.Init(context.Background(), s.config.Tracing)
+	if err != nil {
+		return err
+	}
+	s.shutdownTracing = shutdownTracing
+
 	// create dependencies
-	var repo repository.Repository
-	if s.config.WithMongoDB {
-		provider, err := mongo.NewProvider(mongo.FromEnv())
-		if err != nil {
-			return fmt.Errorf("failed to initialize mongodb provider: %w", err)
-		} else if err = provider.Ping(); err != nil {
-			return fmt.Errorf("failed to ping mongo client: %w", err)
-		}
-		slog.Info("Saving moves to MongoDB")
-		repo = repository.NewMongoRepository(provider.DB())
-	} else {
-		slog.Info("Using mock repository")
-		repo = repository.NewMockRepository()
+	repo, err := s.initRepository()
+	if err != nil {
+		return err
 	}
+	repo = repository.NewTracedRepository(repo, s.config.ParseStorage())
+	s.registerWebhookBots()
+	store := gamesessions.NewMemorySessionStore()
 	service := services.NewGameService(repo)
-	handle := handlers.New(service)
+	service.SetReplayCache(s.config.ParseReplayCacheDir())
+	store.SetRestore(func(id string) (*connectfour.Game, bool) {
+		return restoreGame(service, id)
+	})
+	users := services.NewUserService(repo)
+	matchmaker := services.NewMatchmakingService(matchmaking.NewMemoryQueue(matchmaking.DefaultConfig()), store, repo)
+	limits := s.config.ParseRateLimit()
+	recordRateLimits(limits)
+	bots := services.NewBotRunner(service, services.BotRunnerConfig{
+		Workers: limits.BotSearches,
+		Queue:   limits.BotQueue,
+		Delay:   s.config.ParseBotMoveDelay(),
+	})
+	handle := handlers.New(store, service, users, matchmaker, bots)
+	handle.SetPublicURL(s.config.ParsePublicURL())
+	s.repo, s.store, s.service, s.matchmaker, s.bots = repo, store, service, matchmaker, bots
 
 	// initialize gin router
 	gin.SetMode(s.config.ParseGinMode())
-	r := gin.New()
-	r.Use(gin.Recovery())
+	r, err := newRouter(s.config)
+	if err != nil {
+		return err
+	}
+
+	// probes and metrics get their own group before the rest of the middleware is added, so
+	// they aren't traced, logged or given a session cookie
+	s.registerProbes(r.Group(""))
+
+	r.Use(otelgin.Middleware(tracing.ServiceName))
 
 	// init cors
 	corsConfig := cors.DefaultConfig()
@@ -68,37 +125,249 @@ func (s *Server) init() error {
 
 	// init cookie store
 	secret := os.Getenv("COOKIE_SECRET")
-	store := cookie.NewStore([]byte(secret))
-	session := sessions.Sessions("connect_four", store)
+	cookieStore := cookie.NewStore([]byte(secret))
+	session := sessions.Sessions("connect_four", cookieStore)
 	r.Use(session)
 
 	// register middleware
 	r.Use(sessionMiddleware)
+	r.Use(userMiddleware)
 	r.Use(logMiddleware)
 
+	// requests that start bot searches or render replay GIFs are rate limited
+	limit := rateLimitMiddleware(ratelimit.NewLimiter(limits.Session), ratelimit.NewLimiter(limits.IP))
+
 	// register handlers
 	r.GET("/", handle.Home)
 	r.GET("/game", handle.GetGame)
-	r.POST("/game", handle.CreateGame)
+	r.POST("/game", limit, handle.CreateGame)
 	r.GET("/game/stream", handle.StreamGame)
-	r.POST("/game/move", handle.MakeMove)
-	r.POST("/game/restart", handle.RestartGame)
+	r.POST("/game/move", limit, handle.MakeMove)
+	r.POST("/game/restart", limit, handle.RestartGame)
+	r.POST("/game/rematch", limit, handle.Rematch)
 	r.POST("/game/stop", handle.StopGame)
-	r.POST("/bot/config", handle.ConfigureBot)
+	r.POST("/game/resume", limit, handle.ResumeGame)
+	r.POST("/game/step", limit, handle.StepGame)
+	r.POST("/game/resign", handle.Resign)
+	r.POST("/game/draw/offer", handle.OfferDraw)
+	r.POST("/game/draw/accept", handle.AcceptDraw)
+	r.POST("/game/draw/decline", handle.DeclineDraw)
+	r.GET("/game/evaluation", limit, handle.GetEvaluation)
+	r.POST("/game/evaluation", limit, handle.ToggleEvaluation)
+	r.GET("/game/history/preview", handle.PreviewMove)
+	r.POST("/bot/config", limit, handle.ConfigureBot)
 	r.GET("/settings", handle.Settings)
+	r.GET("/games/:id/replay", handle.ReplayGame)
+	r.GET("/games/:id/image.svg", handle.GameImage)
+	r.GET("/games/:id/image.png", handle.GameImage)
+	r.GET("/games/:id/replay.gif", limit, handle.ReplayGIF)
+	r.GET("/position/image.svg", handle.PositionImage)
+	r.GET("/position/image.png", handle.PositionImage)
+	r.GET("/leaderboard", handle.Leaderboard)
+	r.GET("/api/leaderboard", handle.LeaderboardJSON)
+	r.GET("/api/ws", handle.GameSocket)
+	r.GET("/login", handle.LoginPage)
+	r.POST("/login", handle.Login)
+	r.POST("/register", handle.Register)
+	r.POST("/logout", handle.Logout)
+	r.GET("/profile", handle.Profile)
+	r.POST("/profile", handle.UpdateProfile)
+	r.GET("/matchmaking", handle.Matchmaking)
+	r.POST("/matchmaking", handle.JoinQueue)
+	r.GET("/matchmaking/stream", handle.StreamQueue)
+	r.POST("/matchmaking/leave", handle.LeaveQueue)
 
 	s.router = r
 	return nil
 }
 
+func (s *Server) initRepository() (repository.Repository, error) {
+	switch s.config.ParseStorage() {
+	case config.StorageMongoDB:
+		provider, err := mongo.NewProvider(mongo.FromEnv())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize mongodb provider: %w", err)
+		} else if err = provider.Ping(); err != nil {
+			return nil, fmt.Errorf("failed to ping mongo client: %w", err)
+		}
+		slog.Info("Saving moves to MongoDB")
+		s.provider = provider
+		return repository.NewMongoRepository(provider.DB()), nil
+	case config.StorageSQLite:
+		provider, err := sqlite.NewProvider(sqlite.FromEnv())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize sqlite provider: %w", err)
+		} else if err = provider.Ping(); err != nil {
+			return nil, fmt.Errorf("failed to ping sqlite database: %w", err)
+		}
+		slog.Info("Saving moves to SQLite")
+		s.sqliteProvider = provider
+		return repository.NewSQLiteRepository(provider.DB()), nil
+	default:
+		slog.Info("Using mock repository")
+		return repository.NewMockRepository(), nil
+	}
+}
+
+// newRouter creates the gin engine before any routes or middleware are added.
+func newRouter(cfg *config.ServerConfig) (*gin.Engine, error) {
+	r := gin.New()
+	r.ContextWithFallback = true // handlers pass the gin context on, it needs to carry the request's span
+	// the client IP is only read from X-Forwarded-For behind a trusted proxy, otherwise any
+	// client could pick a fresh IP to be rate limited by
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	r.Use(gin.Recovery())
+	return r, nil
+}
+
+// recordRateLimits exports the configured limits so they can be compared against the rejections.
+func recordRateLimits(limits config.RateLimitConfig) {
+	metrics.RateLimits.WithLabelValues(metrics.ScopeSession, "rate").Set(limits.Session.Rate)
+	metrics.RateLimits.WithLabelValues(metrics.ScopeSession, "burst").Set(float64(limits.Session.Burst))
+	metrics.RateLimits.WithLabelValues(metrics.ScopeIP, "rate").Set(limits.IP.Rate)
+	metrics.RateLimits.WithLabelValues(metrics.ScopeIP, "burst").Set(float64(limits.IP.Burst))
+	metrics.RateLimits.WithLabelValues(metrics.ScopeBotSearch, "concurrency").Set(float64(limits.BotSearches))
+	metrics.RateLimits.WithLabelValues(metrics.ScopeBotSearch, "queue").Set(float64(limits.BotQueue))
+}
+
+// registerWebhookBots makes the configured webhook bots available as strategies.
+func (s *Server) registerWebhookBots() {
+	for _, bot := range s.config.WebhookBots {
+		retries := webhook.DefaultRetries
+		if bot.Retries != nil {
+			retries = *bot.Retries
+		}
+		webhook.Register(webhook.Config{
+			Name:    bot.Name,
+			URL:     bot.URL,
+			Secret:  os.ExpandEnv(bot.Secret),
+			Timeout: bot.Timeout,
+			Retries: retries,
+		})
+		slog.Info("Registered webhook bot", "name", bot.Name, "url", bot.URL)
+	}
+}
+
+// restoreGame rebuilds a session suspended by the last shutdown, if there is one.
+func restoreGame(service *services.GameService, sessionID string) (*connectfour.Game, bool) {
+	game, err := service.RestoreGame(context.Background(), sessionID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrSessionNotFound) {
+			slog.Error("Failed to restore session", "session_id", sessionID, "error", err)
+		}
+		return nil, false
+	}
+	return game, true
+}
+
+// Run serves requests until the process is interrupted or terminated, then shuts down gracefully.
 func (s *Server) Run() error {
 	if err := s.init(); err != nil {
 		return err
 	}
-	slog.Info("Starting server...")
-	return s.router.Run(s.config.Address)
+	s.httpServer = &http.Server{
+		Addr:         s.config.Address,
+		Handler:      s.router,
+		ReadTimeout:  durationOr(s.config.ReadTimeout, defaultReadTimeout),
+		WriteTimeout: s.config.WriteTimeout,
+		IdleTimeout:  durationOr(s.config.IdleTimeout, defaultIdleTimeout),
+	}
+	// streams never end on their own, so they're closed once the listener has stopped accepting
+	// connections, Shutdown then only has to wait for regular requests
+	s.httpServer.RegisterOnShutdown(s.drainStreams)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("Starting server...", "address", s.config.Address)
+		errCh <- s.httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		stop() // a second signal kills the process straight away
+	}
+	return s.shutdown()
+}
+
+func (s *Server) shutdown() error {
+	slog.Info("Shutting down server...")
+	s.shuttingDown.Store(true)
+	s.matchmaker.Close()
+	// bot moves being searched for are played and saved, nothing new is started
+	s.bots.Close()
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), durationOr(s.config.ShutdownTimeout, defaultShutdownTimeout))
+	defer ctxCancel()
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
+		slog.Error("Requests didn't finish before the shutdown timeout", "error", err)
+	}
+
+	// no more moves can be made, so every game is in its final state
+	s.suspendGames()
+	s.store.Close()
+	slog.Info("Server stopped")
+	return err
+}
+
+// drainStreams tells every client the server is restarting and closes their streams.
+func (s *Server) drainStreams() {
+	for _, sess := range s.store.All() {
+		sess.Send(models.EventServerRestarting, models.ServerRestarting{Message: "The server is restarting, your game will be back shortly"})
+		sess.CloseStream()
+	}
+}
+
+// suspendGames saves the game each session is playing so it can be restored after the restart.
+// Online games need both players' sessions and aren't restored.
+func (s *Server) suspendGames() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), suspendTimeout)
+	defer ctxCancel()
+
+	suspended := 0
+	for _, sess := range s.store.All() {
+		if sess.Game() == nil || sess.PlayerID() != "" {
+			continue
+		}
+		game := sess.Game().Snapshot()
+		ok, err := s.service.SuspendGame(ctx, sess.ID, game)
+		if err != nil {
+			slog.Error("Failed to suspend game", "session_id", sess.ID, "game_id", game.ID, "error", err)
+		} else if ok {
+			suspended++
+		}
+	}
+	slog.Info("Suspended in-progress games", "count", suspended)
+}
+
+func durationOr(d, fallback time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return fallback
 }
 
 func (s *Server) Close() error {
-	return s.provider.Close()
+	if s.shutdownTracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}
+	var errs []error
+	if s.sqliteProvider != nil {
+		errs = append(errs, s.sqliteProvider.Close())
+	}
+	if s.provider != nil {
+		errs = append(errs, s.provider.Close())
+	}
+	return errors.Join(errs...)
 }
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/metrics"
+	"github.com/Zach51920/connect-four/internal/sessions"
+	"github.com/Zach51920/connect-four/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrRunnerBusy = errors.New("too many bot games waiting")
+	errStaleMove  = errors.New("game changed during the search")
+)
+
+type BotRunnerConfig struct {
+	// Workers is how many bot moves are searched for at once.
+	Workers int
+	// Queue is how many games can wait for their next move before new games are turned away.
+	Queue int
+	// Delay is the pause before each bot move, so bot games can be followed.
+	Delay time.Duration
+}
+
+// BotRunner plays the bots' moves in the background. Each game gets one move at a time from
+// a pool of workers, so long bot games share the workers with everyone else's.
+type BotRunner struct {
+	service *GameService
+	config  BotRunnerConfig
+	jobs    chan botJob
+	done    chan struct{}
+	wg      sync.WaitGroup
+
+	mu     sync.Mutex
+	games  map[string]*pendingMove // by session ID
+	nextID int
+	closed bool
+}
+
+// botJob is a request to play the next bot move in a session's game. It's dropped if the
+// session has moved on to another game by the time it runs.
+type botJob struct {
+	id   int
+	sess *sessions.Session
+	game *connectfour.SharedGame
+	// step plays a single move in a paused game and leaves it paused
+	step bool
+	// origin is the span of the request that scheduled the bots, every move it leads to is
+	// traced on its own and linked back to it
+	origin trace.SpanContext
+}
+
+// pendingMove is the move a game is waiting on, it's forgotten once the move is played or
+// cancelled so jobs for old moves are skipped.
+type pendingMove struct {
+	jobID int
+	timer *time.Timer
+}
+
+func NewBotRunner(service *GameService, config BotRunnerConfig) *BotRunner {
+	// the queue limit only admits games, a game being searched for queues its next move
+	// regardless, so there's room for one per worker on top
+	r := &BotRunner{
+		service: service,
+		config:  config,
+		jobs:    make(chan botJob, config.Queue+config.Workers),
+		done:    make(chan struct{}),
+		games:   make(map[string]*pendingMove),
+	}
+	for range config.Workers {
+		r.wg.Add(1)
+		go r.work()
+	}
+	return r
+}
+
+// Schedule plays the bots' moves in the session's game until it's a human's turn, the game is
+// over or it's paused. It's a no-op if a move is already pending. The moves are traced linked to
+// the span in ctx.
+func (r *BotRunner) Schedule(ctx context.Context, sess *sessions.Session) error {
+	return r.queue(sess, false, r.config.Delay, true, trace.SpanContextFromContext(ctx))
+}
+
+// Pause stops the game after the move being searched for, if there is one.
+func (r *BotRunner) Pause(sess *sessions.Session) {
+	r.Cancel(sess.ID)
+	_ = sess.Game().Update(func(game *connectfour.Game) error {
+		game.Stop()
+		return nil
+	})
+}
+
+// Resume carries on with a paused game.
+func (r *BotRunner) Resume(ctx context.Context, sess *sessions.Session) error {
+	_ = sess.Game().Update(func(game *connectfour.Game) error {
+		game.Resume()
+		return nil
+	})
+	return r.Schedule(ctx, sess)
+}
+
+// Step plays the next bot move straight away and leaves the game paused.
+func (r *BotRunner) Step(ctx context.Context, sess *sessions.Session) error {
+	r.Cancel(sess.ID)
+	return r.queue(sess, true, 0, true, trace.SpanContextFromContext(ctx))
+}
+
+// Cancel drops the session's pending move, like when its game is restarted.
+func (r *BotRunner) Cancel(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if pending, ok := r.games[sessionID]; ok {
+		pending.timer.Stop()
+		delete(r.games, sessionID)
+	}
+}
+
+// Close stops scheduling moves and waits for the moves being searched for to be played.
+func (r *BotRunner) Close() {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	for id, pending := range r.games {
+		pending.timer.Stop()
+		delete(r.games, id)
+	}
+	close(r.done)
+	r.mu.Unlock()
+	r.wg.Wait()
+}
+
+// queue schedules the session's next move. Counting the games waiting on the delay as well as
+// the ones waiting for a worker, a full queue turns away new games when limit is set. Games
+// already being played aren't limited so they're never left stuck halfway.
+func (r *BotRunner) queue(sess *sessions.Session, step bool, delay time.Duration, limit bool, origin trace.SpanContext) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.games[sess.ID]; ok || r.closed || sess.Game() == nil {
+		return nil
+	}
+	if limit && len(r.games) >= r.config.Queue {
+		return ErrRunnerBusy
+	}
+
+	r.nextID++
+	job := botJob{id: r.nextID, sess: sess, game: sess.Game(), step: step, origin: origin}
+	r.games[sess.ID] = &pendingMove{
+		jobID: job.id,
+		timer: time.AfterFunc(delay, func() { r.enqueue(job) }),
+	}
+	return nil
+}
+
+func (r *BotRunner) enqueue(job botJob) {
+	select {
+	case r.jobs <- job:
+		metrics.BotSearchesQueued.Inc()
+	case <-r.done:
+	}
+}
+
+func (r *BotRunner) work() {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.done:
+			return
+		case job := <-r.jobs:
+			metrics.BotSearchesQueued.Dec()
+			r.advance(job)
+		}
+	}
+}
+
+// advance plays the job's move and schedules the next one.
+func (r *BotRunner) advance(job botJob) {
+	r.mu.Lock()
+	pending, ok := r.games[job.sess.ID]
+	if !ok || pending.jobID != job.id {
+		r.mu.Unlock()
+		return // cancelled while it was waiting
+	}
+	delete(r.games, job.sess.ID)
+	r.mu.Unlock()
+
+	sess, shared := job.sess, job.game
+	if sess.Game() != shared {
+		return // the session started another game
+	}
+	if job.step {
+		_ = shared.Update(func(game *connectfour.Game) error {
+			game.Resume()
+			return nil
+		})
+	}
+	played := r.playTurn(job)
+
+	var botsTurn bool
+	_ = shared.Update(func(game *connectfour.Game) error {
+		if job.step && game.InProgress() {
+			game.Stop()
+		}
+		_, botsTurn = game.CurrentPlayer().(*connectfour.BotPlayer)
+		botsTurn = botsTurn && game.InProgress()
+		return nil
+	})
+	refreshSession(sess)
+
+	if played && botsTurn && !job.step {
+		_ = r.queue(sess, false, r.config.Delay, false, job.origin)
+	}
+}
+
+// playTurn plays a move for the bot whose turn it is, it reports false if there wasn't one.
+// The search runs on a snapshot so the game can still be rendered and commanded meanwhile.
+func (r *BotRunner) playTurn(job botJob) bool {
+	sess, shared := job.sess, job.game
+	// bot games outlive the request that started them, so each move gets its own trace
+	ctx, span := tracing.Tracer().Start(context.Background(), "BotRunner.playTurn",
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.Link{SpanContext: job.origin}),
+		trace.WithAttributes(attribute.String("session.id", sess.ID), attribute.Bool("bot.step", job.step)),
+	)
+	defer span.End()
+	var (
+		botID  string
+		gameID string
+		plies  int
+		col    int
+	)
+	shared.Search(func(player connectfour.Player, game *connectfour.Game) {
+		if bot, ok := player.(*connectfour.BotPlayer); ok && game.InProgress() {
+			metrics.BotSearchesActive.Inc()
+			defer metrics.BotSearchesActive.Dec()
+			botID, gameID, plies = bot.ID(), game.ID, len(game.Moves)
+			col = bot.EvaluateGame(ctx, game)
+		}
+	})
+	if botID == "" {
+		return false
+	}
+
+	err := shared.Update(func(game *connectfour.Game) error {
+		// commands that ran during the search may have stopped, restarted or replaced the game
+		player := game.CurrentPlayer()
+		if game.ID != gameID || len(game.Moves) != plies || player.ID() != botID {
+			return errStaleMove
+		}
+		if err := r.service.MakeMove(ctx, player, game, col); err != nil {
+			return err
+		}
+		game.NextPlayer()
+		return nil
+	})
+	if err != nil {
+		slog.Debug("Dropped bot move", "session_id", sess.ID, "game_id", gameID, "error", err)
+		return false
+	}
+	r.service.RateGame(ctx, shared)
+	return true
+}
+
+// refreshSession re-renders the game for the session, and their opponent in an online game.
+func refreshSession(sess *sessions.Session) {
+	sess.Refresh()
+	if sess.Opponent() != nil {
+		sess.Opponent().Refresh()
+	}
+}
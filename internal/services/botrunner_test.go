@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/models"
+	"github.com/Zach51920/connect-four/internal/repository"
+	"github.com/Zach51920/connect-four/internal/sessions"
+	"github.com/Zach51920/connect-four/internal/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newBotGame() *connectfour.Game {
+	bot1, bot2 := connectfour.NewMinimaxBot('X'), connectfour.NewMinimaxBot('O')
+	for _, bot := range []*connectfour.BotPlayer{bot1, bot2} {
+		bot.Config.SetDifficulty(1).SetMistakeFrequency(0)
+	}
+	return connectfour.NewGame(bot1, bot2)
+}
+
+// waitFor polls until cond holds, bot moves are played on the runner's workers.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+func TestBotRunner(t *testing.T) {
+	store := sessions.NewMemorySessionStore()
+	defer store.Close()
+	runner := NewBotRunner(NewGameService(repository.NewMockRepository()), BotRunnerConfig{Workers: 2, Queue: 4})
+	defer runner.Close()
+	ctx := context.Background()
+
+	t.Run("plays until the game is over", func(t *testing.T) {
+		sess := store.New("finished", newBotGame())
+		if err := runner.Schedule(ctx, sess); err != nil {
+			t.Fatalf("failed to schedule: %v", err)
+		}
+		waitFor(t, "the game to finish", func() bool {
+			runner.mu.Lock()
+			defer runner.mu.Unlock()
+			_, pending := runner.games[sess.ID]
+			return !pending && !sess.Game().Snapshot().InProgress()
+		})
+		if game := sess.Game().Snapshot(); game.State != connectfour.GameStateWin && game.State != connectfour.GameStateDraw {
+			t.Errorf("expected the game to be decided, got state %d", game.State)
+		}
+	})
+
+	t.Run("moves are traced linked to the request", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		defer otel.SetTracerProvider(otel.GetTracerProvider())
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+		reqCtx, request := tracing.Tracer().Start(ctx, "request")
+		sess := store.New("traced", newBotGame())
+		if err := runner.Schedule(reqCtx, sess); err != nil {
+			t.Fatalf("failed to schedule: %v", err)
+		}
+		request.End()
+		waitFor(t, "the game to finish", func() bool {
+			runner.mu.Lock()
+			defer runner.mu.Unlock()
+			_, pending := runner.games[sess.ID]
+			return !pending && !sess.Game().Snapshot().InProgress()
+		})
+
+		turns := make(map[trace.SpanID]bool)
+		for _, span := range recorder.Ended() {
+			if span.Name() != "BotRunner.playTurn" {
+				continue
+			}
+			if links := span.Links(); len(links) != 1 || links[0].SpanContext.SpanID() != request.SpanContext().SpanID() {
+				t.Errorf("expected the move to link to the request, got %v", links)
+			}
+			turns[span.SpanContext().SpanID()] = true
+		}
+		if len(turns) == 0 {
+			t.Fatal("expected the moves to be traced")
+		}
+		for _, span := range recorder.Ended() {
+			if span.Name() == "BotPlayer.Evaluate" && !turns[span.Parent().SpanID()] {
+				t.Errorf("expected the search to be part of a move's trace")
+			}
+		}
+	})
+
+	t.Run("step plays one move and stays paused", func(t *testing.T) {
+		game := newBotGame()
+		game.Stop()
+		sess := store.New("stepped", game)
+		for i := 1; i <= 2; i++ {
+			if err := runner.Step(ctx, sess); err != nil {
+				t.Fatalf("failed to step: %v", err)
+			}
+			waitFor(t, "the step", func() bool { return len(sess.Game().Snapshot().Moves) == i })
+		}
+		time.Sleep(20 * time.Millisecond)
+		game = sess.Game().Snapshot()
+		if moves := len(game.Moves); moves != 2 || game.State != connectfour.GameStateStopped {
+			t.Errorf("expected 2 moves in a stopped game, got %d moves in state %d", moves, game.State)
+		}
+	})
+
+	t.Run("pause cancels the pending move", func(t *testing.T) {
+		slow := NewBotRunner(NewGameService(repository.NewMockRepository()), BotRunnerConfig{Workers: 1, Queue: 1, Delay: time.Hour})
+		defer slow.Close()
+		sess := store.New("paused", newBotGame())
+		if err := slow.Schedule(ctx, sess); err != nil {
+			t.Fatalf("failed to schedule: %v", err)
+		}
+		slow.Pause(sess)
+		if _, pending := slow.games[sess.ID]; pending || sess.Game().Snapshot().State != connectfour.GameStateStopped {
+			t.Errorf("expected the pending move to be dropped and the game stopped, got state %d", sess.Game().Snapshot().State)
+		}
+	})
+
+	t.Run("a full runner turns away new games", func(t *testing.T) {
+		full := NewBotRunner(NewGameService(repository.NewMockRepository()), BotRunnerConfig{Workers: 1, Queue: 1, Delay: time.Hour})
+		defer full.Close()
+		first, second := store.New("queued", newBotGame()), store.New("turned-away", newBotGame())
+		if err := full.Schedule(ctx, first); err != nil {
+			t.Fatalf("failed to schedule: %v", err)
+		}
+		// games waiting on the delay count, not just the ones waiting for a worker
+		if err := full.Schedule(ctx, second); !errors.Is(err, ErrRunnerBusy) {
+			t.Errorf("expected ErrRunnerBusy, got %v", err)
+		}
+		if err := full.Step(ctx, second); !errors.Is(err, ErrRunnerBusy) {
+			t.Errorf("expected ErrRunnerBusy stepping, got %v", err)
+		}
+		if err := full.Schedule(ctx, first); err != nil {
+			t.Errorf("expected scheduling a pending game again to be a no-op, got %v", err)
+		}
+		full.Cancel(first.ID)
+		if err := full.Schedule(ctx, second); err != nil {
+			t.Errorf("expected room once the first game was cancelled, got %v", err)
+		}
+	})
+
+	t.Run("a replaced game isn't played", func(t *testing.T) {
+		quick := NewBotRunner(NewGameService(repository.NewMockRepository()), BotRunnerConfig{Workers: 1, Queue: 1, Delay: 10 * time.Millisecond})
+		defer quick.Close()
+		sess := store.New("replaced", newBotGame())
+		if err := quick.Schedule(ctx, sess); err != nil {
+			t.Fatalf("failed to schedule: %v", err)
+		}
+		sess.SetGame(newBotGame())
+		waitFor(t, "the stale move to be dropped", func() bool {
+			quick.mu.Lock()
+			defer quick.mu.Unlock()
+			_, pending := quick.games[sess.ID]
+			return !pending
+		})
+		if moves := len(sess.Game().Snapshot().Moves); moves != 0 {
+			t.Errorf("expected the new game to wait to be started, got %d moves", moves)
+		}
+		if err := quick.Schedule(ctx, sess); err != nil {
+			t.Errorf("expected the new game to be scheduled, got %v", err)
+		}
+	})
+
+	t.Run("commands race the bot moves", func(t *testing.T) {
+		sess := store.New("raced", newBotGame())
+		service := NewGameService(repository.NewMockRepository())
+		var wg sync.WaitGroup
+		for worker := range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 50 {
+					switch (worker + i) % 4 {
+					case 0:
+						_ = runner.Schedule(ctx, sess)
+					case 1:
+						runner.Pause(sess)
+					case 2:
+						_ = runner.Step(ctx, sess)
+					default:
+						runner.Cancel(sess.ID)
+						_ = sess.Game().Update(func(game *connectfour.Game) error {
+							game.Restart()
+							return service.UpdateBotConfig(game.Players, models.BotConfigRequest{ID: game.Players[0].ID(), Difficulty: 1 + i%2})
+						})
+					}
+					_ = len(sess.Game().Snapshot().Moves)
+				}
+			}()
+		}
+		wg.Wait()
+		runner.Cancel(sess.ID)
+	})
+}
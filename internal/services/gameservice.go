@@ -3,41 +3,88 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/metrics"
 	"github.com/Zach51920/connect-four/internal/models"
 	"github.com/Zach51920/connect-four/internal/repository"
+	"github.com/Zach51920/connect-four/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"log/slog"
+	"time"
 )
 
 type GameService struct {
-	repository repository.Repository
+	repository       repository.Repository
+	replayCache      string
+	replayCacheLimit int
 }
 
 func NewGameService(repo repository.Repository) *GameService {
 	return &GameService{repository: repo}
 }
 
-func (s *GameService) CreateGame(req models.CreateGameRequest) (*connectfour.Game, error) {
+func (s *GameService) CreateGame(req models.CreateGameRequest, user *repository.User) (*connectfour.Game, error) {
+	// signed in users play with their preferred colour, their opponent gets the other one
+	token, opToken := 'X', 'O'
+	if user != nil {
+		token = models.ColorToken(user.Color)
+		opToken = 'X' + 'O' - token
+	}
+
 	// create the players according to the game type
 	var player1, player2 connectfour.Player
+	var err error
 	switch req.Type {
 	case models.GameTypeBot:
-		player1 = connectfour.NewHumanPlayer("Player 1", 'X')
-		player2 = connectfour.NewMinimaxBot('O')
+		player1 = humanPlayer(user, "Player 1", token)
+		player2, err = newBot(req.Strategy, opToken)
 	case models.GameTypeLocal:
-		player1, player2 = connectfour.NewHumanPlayerPair()
+		player1 = humanPlayer(user, "Player1", token)
+		player2 = connectfour.NewHumanPlayer("Player2", opToken)
 	case models.GameTypeBotOnly:
 		player1 = connectfour.NewMinimaxBot('X')
-		player2 = connectfour.NewMinimaxBot('O')
+		player2, err = newBot(req.Strategy, 'O')
 	default:
 		return nil, errors.New("unknown game type")
 	}
+	if err != nil {
+		return nil, err
+	}
 
 	// create and save the game
 	game := connectfour.NewGame(player1, player2)
+	if req.BestOf > 1 {
+		if err = game.SetBestOf(req.BestOf); err != nil {
+			return nil, err
+		}
+	}
+	metrics.GamesCreated.WithLabelValues(req.Type).Inc()
 	return game, nil
 }
 
+// newBot creates a bot playing the named strategy, named after it. Minimax bots get a random name.
+func newBot(strategy string, token rune) (connectfour.Player, error) {
+	if strategy == "" || strategy == connectfour.StrategyMinimax {
+		return connectfour.NewMinimaxBot(token), nil
+	}
+	config := connectfour.DefaultConfig()
+	strat, err := connectfour.NewStrategy(strategy, config)
+	if err != nil {
+		return nil, err
+	}
+	return connectfour.NewBotPlayer(strategy, token, config, strat), nil
+}
+
+// humanPlayer creates the player for a signed in user, or an anonymous player if there isn't one.
+func humanPlayer(user *repository.User, name string, token rune) *connectfour.HumanPlayer {
+	if user == nil {
+		return connectfour.NewHumanPlayer(name, token)
+	}
+	return connectfour.NewUserPlayer(user.ID, user.DisplayName, token)
+}
+
 func (s *GameService) UpdateBotConfig(players [2]connectfour.Player, req models.BotConfigRequest) error {
 	for _, player := range players {
 		if player.ID() != req.ID {
@@ -51,30 +98,183 @@ func (s *GameService) UpdateBotConfig(players [2]connectfour.Player, req models.
 		bot.Config.SetDifficulty(req.Difficulty).
 			SetMistakeFrequency(req.MistakeFrequency).
 			IncludeRandomization(req.IsRandom == "on")
+		if req.Seed != nil && *req.Seed != bot.Config.Seed {
+			bot.Config.SetSeed(*req.Seed)
+		}
 		break
 	}
 	return nil
 }
 
-func (s *GameService) MakeMove(ctx context.Context, player connectfour.Player, game *connectfour.Game, col int) error {
-	if player != game.CurrentPlayer() {
-		return errors.New("not players turn")
-	}
+func (s *GameService) MakeMove(ctx context.Context, player connectfour.Player, game *connectfour.Game, col int) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "GameService.MakeMove", trace.WithAttributes(
+		attribute.String("game.id", game.ID),
+		attribute.String("player.id", player.ID()),
+		attribute.Int("move.column", col),
+	))
+	defer tracing.End(span, &err)
 
-	// insert the token
-	if game.Board.IsColumnFull(col) {
-		return connectfour.ErrInvalidMove
+	if _, err = game.Play(player, col); err != nil {
+		return err
+	}
+	if !game.InProgress() {
+		countFinished(game)
 	}
-	game.Board.Insert(player.Token(), col)
-	game.RefreshState()
-	game.IncMoveCount()
-
-	// update the players score
-	score := connectfour.CalculateScore(player, game.Board)
-	player.AddScore(score)
 
 	if err := s.repository.SaveMove(ctx, game, player, col); err != nil {
 		slog.Error("failed to save move", "error", err)
 	}
+	s.recordSeries(ctx, game)
 	return nil
 }
+
+func (s *GameService) Resign(ctx context.Context, game *connectfour.Game, player connectfour.Player) error {
+	if err := game.Resign(player); err != nil {
+		return err
+	}
+	s.finishGame(ctx, game)
+	return nil
+}
+
+// OfferDraw offers the players opponent a draw. Bots answer straight away, so it reports whether
+// the game ended in a draw.
+func (s *GameService) OfferDraw(ctx context.Context, game *connectfour.Game, player connectfour.Player) (bool, error) {
+	if err := game.OfferDraw(player); err != nil {
+		return false, err
+	}
+
+	bot, ok := game.Opponent(player).(*connectfour.BotPlayer)
+	if !ok {
+		return false, nil
+	}
+	if !bot.AcceptsDraw(game.Board) {
+		slog.Debug("Bot declined draw offer", "bot", bot.ID())
+		return false, game.DeclineDraw(bot)
+	}
+	return true, s.AcceptDraw(ctx, game, bot)
+}
+
+func (s *GameService) AcceptDraw(ctx context.Context, game *connectfour.Game, player connectfour.Player) error {
+	if err := game.AcceptDraw(player); err != nil {
+		return err
+	}
+	s.finishGame(ctx, game)
+	return nil
+}
+
+// finishGame saves a game that ended away from the board, it's rated by RateGame once the
+// command is done.
+func (s *GameService) finishGame(ctx context.Context, game *connectfour.Game) {
+	countFinished(game)
+	if err := s.repository.SaveResult(ctx, game); err != nil {
+		slog.Error("failed to save result", "game_id", game.ID, "error", err)
+	}
+	s.recordSeries(ctx, game)
+}
+
+// countFinished records a game that has just ended.
+func countFinished(game *connectfour.Game) {
+	metrics.GamesFinished.WithLabelValues(string(game.Result)).Inc()
+	metrics.MovesPerGame.Observe(float64(len(game.Moves)))
+}
+
+// recordSeries adds a finished game to its series, single games aren't worth saving.
+func (s *GameService) recordSeries(ctx context.Context, game *connectfour.Game) {
+	if game.Series.BestOf <= 1 || !game.Series.Record(game) {
+		return
+	}
+	if err := s.repository.SaveSeries(ctx, game.Series); err != nil {
+		slog.Error("failed to save series", "series_id", game.Series.ID, "error", err)
+	}
+}
+
+func (s *GameService) LoadReplay(ctx context.Context, id string, req models.ReplayRequest) (*models.GameReplay, error) {
+	stored, err := s.repository.GetGame(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	plies := len(stored.Moves)
+	ply := plies
+	if req.Ply != nil {
+		ply = max(0, min(*req.Ply, plies))
+	}
+	speed := req.Speed
+	if speed <= 0 {
+		speed = models.DefaultReplaySpeed
+	}
+
+	game, err := replayStored(stored, ply)
+	if err != nil {
+		return nil, err
+	}
+	return &models.GameReplay{Game: game, Ply: ply, Plies: plies, Speed: speed}, nil
+}
+
+// replayStored rebuilds a stored game up to the given ply.
+func replayStored(stored *repository.Game, ply int) (*connectfour.Game, error) {
+	game, err := connectfour.Replay(stored.Setup(), stored.ReplayMoves()[:ply])
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay game %s: %w", stored.ID, err)
+	}
+
+	// the moves don't show how games that ended away from the board finished
+	if ply == len(stored.Moves) {
+		switch connectfour.Result(stored.Result) {
+		case connectfour.ResultResignation:
+			if player, ok := game.FindPlayer(stored.ResignedBy); ok {
+				_ = game.Resign(player)
+			}
+		case connectfour.ResultAgreement:
+			game.State, game.Result = connectfour.GameStateDraw, connectfour.ResultAgreement
+		}
+	}
+	return game, nil
+}
+
+// Position plays a move string, like "ddcef", from the start and returns the board it leaves.
+func (s *GameService) Position(moves string) (*connectfour.Board, error) {
+	cols, err := connectfour.ParseColumns(moves)
+	if err != nil {
+		return nil, err
+	}
+	game := connectfour.NewGame(connectfour.NewHumanPlayerPair())
+	for i, col := range cols {
+		if _, err = game.Play(game.CurrentPlayer(), col); err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		game.NextPlayer()
+	}
+	return game.Board, nil
+}
+
+// SuspendGame remembers the game the session is playing so it can be restored after a restart.
+// Finished games and games without a saved move have nothing worth restoring.
+func (s *GameService) SuspendGame(ctx context.Context, sessionID string, game *connectfour.Game) (bool, error) {
+	if !game.InProgress() && game.State != connectfour.GameStateStopped || len(game.Moves) == 0 {
+		return false, nil
+	}
+	session := &repository.SuspendedSession{ID: sessionID, GameID: game.ID, SuspendedAt: time.Now()}
+	if err := s.repository.SaveSession(ctx, session); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RestoreGame rebuilds the game the session was playing when the server last shut down. The
+// game is restored on its own, without the rest of its series.
+func (s *GameService) RestoreGame(ctx context.Context, sessionID string) (*connectfour.Game, error) {
+	session, err := s.repository.TakeSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.repository.GetGame(ctx, session.GameID)
+	if err != nil {
+		return nil, err
+	}
+	game, err := connectfour.Replay(stored.Setup(), stored.ReplayMoves())
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay game %s: %w", session.GameID, err)
+	}
+	return game, nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/models"
+	"github.com/Zach51920/connect-four/internal/repository"
+)
+
+func (s *GameService) Leaderboard(ctx context.Context, req models.LeaderboardRequest) (*models.Leaderboard, error) {
+	board := &models.Leaderboard{View: req.View, Window: req.Window}
+	if !slices.Contains(repository.LeaderboardViews, board.View) {
+		board.View = repository.LeaderboardRatings
+	}
+
+	query := repository.LeaderboardQuery{View: board.View, Limit: models.LeaderboardLimit}
+	switch board.Window {
+	case models.LeaderboardWindowWeek:
+		query.Since = time.Now().AddDate(0, 0, -7)
+	case models.LeaderboardWindowMonth:
+		query.Since = time.Now().AddDate(0, -1, 0)
+	default:
+		board.Window = models.LeaderboardWindowAll
+	}
+
+	entries, err := s.repository.Leaderboard(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	board.Entries = entries
+	return board, nil
+}
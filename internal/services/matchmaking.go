@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/matchmaking"
+	"github.com/Zach51920/connect-four/internal/models"
+	"github.com/Zach51920/connect-four/internal/ratings"
+	"github.com/Zach51920/connect-four/internal/repository"
+	"github.com/Zach51920/connect-four/internal/sessions"
+)
+
+var ErrInvalidTimeControl = errors.New("unknown time control")
+
+// MatchmakingService puts sessions into the queue and starts their game once the queue pairs them.
+type MatchmakingService struct {
+	queue      matchmaking.Queue
+	sessions   sessions.Store
+	repository repository.Repository
+}
+
+func NewMatchmakingService(queue matchmaking.Queue, store sessions.Store, repo repository.Repository) *MatchmakingService {
+	s := &MatchmakingService{queue: queue, sessions: store, repository: repo}
+	go s.start()
+	return s
+}
+
+func (s *MatchmakingService) Join(ctx context.Context, sess *sessions.Session, user *repository.User, req models.MatchmakingRequest) (models.QueueStatus, error) {
+	if !slices.Contains(matchmaking.TimeControls, req.TimeControl) {
+		return models.QueueStatus{}, ErrInvalidTimeControl
+	}
+
+	// anonymous players are matched as if they had the default rating
+	ticket := matchmaking.Ticket{
+		SessionID:   sess.ID,
+		Name:        guestName(req.Name),
+		Rating:      ratings.DefaultRating,
+		TimeControl: req.TimeControl,
+	}
+	if user != nil {
+		ticket.UserID, ticket.Name = user.ID, user.DisplayName
+		rating, err := s.repository.GetRating(ctx, ratings.UserID(user.ID))
+		if err == nil {
+			ticket.Rating = rating.Rating
+		} else if !errors.Is(err, repository.ErrRatingNotFound) {
+			return models.QueueStatus{}, err
+		}
+	}
+
+	if err := s.queue.Join(ticket); err != nil {
+		return models.QueueStatus{}, err
+	}
+	slog.Debug("Joined matchmaking queue", "session_id", sess.ID, "rating", ticket.Rating, "time_control", ticket.TimeControl)
+	return models.QueueStatus{TimeControl: ticket.TimeControl, Rating: int(ticket.Rating)}, nil
+}
+
+// guestName cleans up the name a player without an account asked for.
+func guestName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "Guest"
+	}
+	if len([]rune(name)) > maxDisplayNameLength {
+		name = string([]rune(name)[:maxDisplayNameLength])
+	}
+	return name
+}
+
+func (s *MatchmakingService) Leave(sessionID string) {
+	if s.queue.Leave(sessionID) {
+		slog.Debug("Left matchmaking queue", "session_id", sessionID)
+	}
+}
+
+func (s *MatchmakingService) Close() {
+	s.queue.Close()
+}
+
+func (s *MatchmakingService) start() {
+	for event := range s.queue.Events() {
+		switch event.Type {
+		case matchmaking.EventWaiting:
+			ticket := event.Tickets[0]
+			if sess, ok := s.sessions.Get(ticket.SessionID); ok {
+				sess.Send(models.EventQueueUpdate, models.QueueStatus{
+					TimeControl: ticket.TimeControl,
+					Rating:      int(ticket.Rating),
+					Waiting:     event.Waiting,
+					Band:        int(event.Band),
+					Queued:      event.Queued,
+				})
+			}
+		case matchmaking.EventTimedOut:
+			ticket := event.Tickets[0]
+			if sess, ok := s.sessions.Get(ticket.SessionID); ok {
+				sess.Send(models.EventQueueTimeout, models.QueueStatus{
+					TimeControl: ticket.TimeControl,
+					Rating:      int(ticket.Rating),
+					Waiting:     event.Waiting,
+				})
+			}
+		case matchmaking.EventMatched:
+			s.startGame(event.Tickets[0], event.Tickets[1])
+		}
+	}
+}
+
+// startGame creates the game for a pair of tickets, the player who waited longest moves first.
+func (s *MatchmakingService) startGame(first, second matchmaking.Ticket) {
+	sess1, ok1 := s.sessions.Get(first.SessionID)
+	sess2, ok2 := s.sessions.Get(second.SessionID)
+	if !ok1 || !ok2 {
+		// one of the sessions expired while waiting, put the other one back in the queue
+		slog.Warn("Matched session no longer exists", "session_1", first.SessionID, "session_2", second.SessionID)
+		for _, ticket := range []matchmaking.Ticket{first, second} {
+			if _, ok := s.sessions.Get(ticket.SessionID); ok {
+				_ = s.queue.Join(ticket)
+			}
+		}
+		return
+	}
+
+	player1, player2 := onlinePlayer(first, 'X'), onlinePlayer(second, 'O')
+	game := connectfour.NewGame(player1, player2)
+	shared := connectfour.NewSharedGame(game)
+	sess1.SetOnlineGame(shared, player1.ID(), sess2)
+	sess2.SetOnlineGame(shared, player2.ID(), sess1)
+	slog.Info("Starting online game", "game_id", game.ID, "player_1", player1.ID(), "player_2", player2.ID())
+
+	sess1.Send(models.EventMatchFound, models.MatchFound{GameID: game.ID, PlayerID: player1.ID(), Opponent: player2.Name()})
+	sess2.Send(models.EventMatchFound, models.MatchFound{GameID: game.ID, PlayerID: player2.ID(), Opponent: player1.Name()})
+	sess1.Refresh()
+	sess2.Refresh()
+}
+
+func onlinePlayer(ticket matchmaking.Ticket, token rune) *connectfour.HumanPlayer {
+	if ticket.UserID == "" {
+		return connectfour.NewHumanPlayer(ticket.Name, token)
+	}
+	return connectfour.NewUserPlayer(ticket.UserID, ticket.Name, token)
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/ratings"
+	"github.com/Zach51920/connect-four/internal/repository"
+)
+
+// ratingAttempts is how many times a rating update is retried when another game changes the
+// rating first, bots are rated in every game they play so their ratings are often contended.
+const ratingAttempts = 10
+
+// RateGame rates a finished game and records the changes on it so they can be shown to the
+// players. The ratings are saved without holding the game, it's only claimed so that it's rated
+// once, then updated with the changes.
+func (s *GameService) RateGame(ctx context.Context, shared *connectfour.SharedGame) {
+	var game *connectfour.Game
+	_ = shared.Update(func(g *connectfour.Game) error {
+		if g.RatingChanges == nil && (g.State == connectfour.GameStateWin || g.State == connectfour.GameStateDraw) {
+			g.RatingChanges = make(map[string]connectfour.RatingChange, len(g.Players))
+			game = g.Snapshot()
+		}
+		return nil
+	})
+	if game == nil {
+		return
+	}
+
+	changes := s.rateGame(ctx, game)
+	_ = shared.Update(func(g *connectfour.Game) error {
+		// the game may have been restarted while it was rated
+		if g.ID == game.ID {
+			maps.Copy(g.RatingChanges, changes)
+		}
+		return nil
+	})
+}
+
+// rateGame updates the rating of both players, returning the changes keyed by player ID.
+func (s *GameService) rateGame(ctx context.Context, game *connectfour.Game) map[string]connectfour.RatingChange {
+	var stored [2]*repository.Rating
+	for i, player := range game.Players {
+		rating, err := s.loadRating(ctx, player)
+		if errors.Is(err, errNotRated) {
+			slog.Debug("skipping rating update, player isn't rated", "player_id", player.ID())
+			return nil
+		} else if err != nil {
+			slog.Error("failed to load rating", "player_id", player.ID(), "error", err)
+			return nil
+		}
+		stored[i] = rating
+	}
+	if stored[0].SubjectID == stored[1].SubjectID {
+		slog.Debug("skipping rating update, players share a rating", "subject_id", stored[0].SubjectID)
+		return nil
+	}
+
+	changes := make(map[string]connectfour.RatingChange, len(game.Players))
+	for i, player := range game.Players {
+		score := ratings.ScoreDraw
+		if game.Winner != nil {
+			score = ratings.ScoreLoss
+			if game.Winner == player {
+				score = ratings.ScoreWin
+			}
+		}
+
+		// each player is rated against the opponent's rating from before the game, only their own
+		// rating is read again when another game saved it first
+		rating, opponent := stored[i], stored[1-i]
+		for attempt := 1; ; attempt++ {
+			change, err := s.saveRating(ctx, game.ID, rating, opponent, score)
+			if err == nil {
+				changes[player.ID()] = change
+				break
+			}
+			if !errors.Is(err, repository.ErrRatingConflict) || attempt == ratingAttempts {
+				slog.Error("failed to save rating", "subject_id", rating.SubjectID, "error", err)
+				break
+			}
+			if rating, err = s.loadRating(ctx, player); err != nil {
+				slog.Error("failed to load rating", "player_id", player.ID(), "error", err)
+				break
+			}
+		}
+	}
+	return changes
+}
+
+// saveRating saves the rating updated with the result against the opponent.
+func (s *GameService) saveRating(ctx context.Context, gameID string, rating, opponent *repository.Rating, score float64) (connectfour.RatingChange, error) {
+	now := time.Now()
+	updated := ratings.Update(toRating(rating), toRating(opponent), score)
+	history := repository.RatingHistory{
+		SubjectID:  rating.SubjectID,
+		GameID:     gameID,
+		Before:     rating.Rating,
+		After:      updated.Rating,
+		Deviation:  updated.Deviation,
+		Volatility: updated.Volatility,
+		Timestamp:  now,
+	}
+	next := *rating
+	next.Rating, next.Deviation, next.Volatility = updated.Rating, updated.Deviation, updated.Volatility
+	next.Games++
+	next.UpdatedAt = now
+	if err := s.repository.SaveRating(ctx, &next, history); err != nil {
+		return connectfour.RatingChange{}, err
+	}
+	return connectfour.RatingChange{Before: rating.Rating, After: updated.Rating}, nil
+}
+
+var errNotRated = errors.New("player is not rated")
+
+func (s *GameService) loadRating(ctx context.Context, player connectfour.Player) (*repository.Rating, error) {
+	id, kind, initial := ratings.SubjectID(player)
+	if id == "" {
+		return nil, errNotRated
+	}
+	rating, err := s.repository.GetRating(ctx, id)
+	if errors.Is(err, repository.ErrRatingNotFound) {
+		return &repository.Rating{
+			SubjectID:  id,
+			Kind:       kind,
+			Name:       ratings.SubjectName(player),
+			Rating:     initial.Rating,
+			Deviation:  initial.Deviation,
+			Volatility: initial.Volatility,
+		}, nil
+	}
+	return rating, err
+}
+
+func toRating(r *repository.Rating) ratings.Rating {
+	return ratings.Rating{Rating: r.Rating, Deviation: r.Deviation, Volatility: r.Volatility}
+}
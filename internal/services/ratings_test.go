@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/ratings"
+	"github.com/Zach51920/connect-four/internal/repository"
+	"github.com/Zach51920/connect-four/internal/sqlite"
+)
+
+// TestGameService_RateGame rates games against the same bot configuration at once, every
+// result has to count towards the bot's shared rating.
+func TestGameService_RateGame(t *testing.T) {
+	provider, err := sqlite.NewProvider(&sqlite.Config{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Close()
+	service := NewGameService(repository.NewSQLiteRepository(provider.DB()))
+	ctx := context.Background()
+
+	const games = 8
+	shared := make([]*connectfour.SharedGame, games)
+	for i := range shared {
+		user := connectfour.NewUserPlayer(fmt.Sprintf("user%d", i), "Player", 'X')
+		game := connectfour.NewGame(user, connectfour.NewMinimaxBot('O'))
+		if err = game.Resign(user); err != nil {
+			t.Fatalf("failed to resign: %v", err)
+		}
+		shared[i] = connectfour.NewSharedGame(game)
+	}
+
+	var wg sync.WaitGroup
+	for _, game := range shared {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			service.RateGame(ctx, game)
+		}()
+	}
+	wg.Wait()
+
+	bot := shared[0].Snapshot().Players[1].(*connectfour.BotPlayer)
+	rating, err := service.repository.GetRating(ctx, ratings.BotID(bot))
+	if err != nil {
+		t.Fatalf("failed to get the bot's rating: %v", err)
+	}
+	if rating.Games != games {
+		t.Errorf("expected the bot to be rated for %d games, got %d", games, rating.Games)
+	}
+	for i, game := range shared {
+		if changes := game.Snapshot().RatingChanges; len(changes) != 2 {
+			t.Errorf("expected game %d to record both rating changes, got %v", i, changes)
+		}
+	}
+
+	// a game is only rated once
+	service.RateGame(ctx, shared[0])
+	if rating, err = service.repository.GetRating(ctx, ratings.BotID(bot)); err != nil || rating.Games != games {
+		t.Errorf("expected the game not to be rated again, got %+v %v", rating, err)
+	}
+}
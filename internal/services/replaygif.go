@@ -0,0 +1,178 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/boardimage"
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/models"
+)
+
+const (
+	// gifFinalDelay holds the final position, so the winning four can be seen before it loops
+	gifFinalDelay = 3 * time.Second
+	// maxCachedGIFs bounds the replay cache, the least recently written GIFs are removed first
+	maxCachedGIFs = 1000
+)
+
+// SetReplayCache sets the directory replay GIFs of finished games are kept in, they never change
+// once the game is over. Without one every GIF is rendered on request.
+func (s *GameService) SetReplayCache(dir string) {
+	s.replayCache = dir
+	s.replayCacheLimit = maxCachedGIFs
+}
+
+// gifDelay snaps the requested delay to the closest replay speed, so each game only has a few
+// GIFs to cache.
+func gifDelay(requested int) time.Duration {
+	speed := models.DefaultReplaySpeed
+	if requested > 0 {
+		for _, s := range models.ReplaySpeeds {
+			if abs(s-requested) < abs(speed-requested) {
+				speed = s
+			}
+		}
+	}
+	return time.Duration(speed) * time.Millisecond
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ReplayGIF renders a stored game as an animated GIF, one frame per ply. It reports whether the
+// game is finished, unfinished games can still get new moves.
+func (s *GameService) ReplayGIF(ctx context.Context, id string, req models.ReplayGIFRequest) ([]byte, bool, error) {
+	delay := gifDelay(req.Delay)
+	themeName := req.Theme
+	if _, ok := boardimage.Themes[themeName]; !ok {
+		themeName = boardimage.DefaultTheme
+	}
+	key := fmt.Sprintf("%s-%d-%s-%t", id, delay.Milliseconds(), themeName, req.Coordinates)
+	if data, ok := s.cachedGIF(key); ok {
+		return data, true, nil
+	}
+
+	stored, err := s.repository.GetGame(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	frames := make([]*connectfour.Board, 0, len(stored.Moves)+1)
+	var game *connectfour.Game
+	for ply := range len(stored.Moves) + 1 {
+		if game, err = replayStored(stored, ply); err != nil {
+			return nil, false, err
+		}
+		frames = append(frames, game.Board)
+	}
+
+	caption := fmt.Sprintf("%s vs %s", game.Players[0].Name(), game.Players[1].Name())
+	if message := game.ResultMessage(); message != "" {
+		caption += ": " + message
+	}
+	theme := boardimage.Themes[themeName]
+	anim := boardimage.Animation{Frames: frames, Caption: caption, Delay: delay, FinalDelay: gifFinalDelay}
+	opts := boardimage.Options{Theme: &theme, Coordinates: req.Coordinates, Highlight: true, LastMove: true}
+
+	var buf bytes.Buffer
+	if err = boardimage.GIF(&buf, anim, opts); err != nil {
+		return nil, false, fmt.Errorf("failed to render replay of game %s: %w", id, err)
+	}
+	finished := !game.InProgress()
+	if finished {
+		s.cacheGIF(key, buf.Bytes())
+	}
+	return buf.Bytes(), finished, nil
+}
+
+// gifPath is where the GIF for the key is cached, keys are hashed so they're safe file names.
+func (s *GameService) gifPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.replayCache, hex.EncodeToString(sum[:])+".gif")
+}
+
+func (s *GameService) cachedGIF(key string) ([]byte, bool) {
+	if s.replayCache == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(s.gifPath(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// cacheGIF saves the GIF, it's written to a temporary file first so a half written GIF is
+// never served. Failing to cache is only logged, the GIF is rendered again next time.
+func (s *GameService) cacheGIF(key string, data []byte) {
+	if s.replayCache == "" {
+		return
+	}
+	if err := os.MkdirAll(s.replayCache, 0o755); err != nil {
+		slog.Error("Failed to create replay cache", "dir", s.replayCache, "error", err)
+		return
+	}
+	tmp, err := os.CreateTemp(s.replayCache, "*.tmp")
+	if err != nil {
+		slog.Error("Failed to cache replay", "error", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.gifPath(key))
+	}
+	if err != nil {
+		slog.Error("Failed to cache replay", "error", err)
+		return
+	}
+	s.pruneGIFs()
+}
+
+// pruneGIFs removes the oldest GIFs once the cache holds more than its limit.
+func (s *GameService) pruneGIFs() {
+	entries, err := os.ReadDir(s.replayCache)
+	if err != nil {
+		slog.Error("Failed to read replay cache", "dir", s.replayCache, "error", err)
+		return
+	}
+	type cachedFile struct {
+		path    string
+		modTime time.Time
+	}
+	files := make([]cachedFile, 0, len(entries))
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".gif" {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			files = append(files, cachedFile{filepath.Join(s.replayCache, entry.Name()), info.ModTime()})
+		}
+	}
+	if len(files) <= s.replayCacheLimit {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, file := range files[:len(files)-s.replayCacheLimit] {
+		if err := os.Remove(file.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			slog.Error("Failed to prune replay cache", "path", file.path, "error", err)
+		}
+	}
+}
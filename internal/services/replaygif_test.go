@@ -0,0 +1,111 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/models"
+	"github.com/Zach51920/connect-four/internal/repository"
+	"github.com/Zach51920/connect-four/internal/sqlite"
+)
+
+func TestGameService_ReplayGIF(t *testing.T) {
+	provider, err := sqlite.NewProvider(&sqlite.Config{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Close()
+	service := NewGameService(repository.NewSQLiteRepository(provider.DB()))
+	cacheDir := t.TempDir()
+	service.SetReplayCache(cacheDir)
+
+	// play moves through the service so they're saved
+	ctx := context.Background()
+	play := func(cols ...int) *connectfour.Game {
+		game := connectfour.NewGame(connectfour.NewHumanPlayerPair())
+		for _, col := range cols {
+			if err := service.MakeMove(ctx, game.CurrentPlayer(), game, col); err != nil {
+				t.Fatalf("failed to play column %d: %v", col, err)
+			}
+			game.NextPlayer()
+		}
+		return game
+	}
+	cached := func() int {
+		entries, err := os.ReadDir(cacheDir)
+		if err != nil {
+			t.Fatalf("failed to read the cache: %v", err)
+		}
+		return len(entries)
+	}
+
+	t.Run("finished games are cached", func(t *testing.T) {
+		game := play(0, 0, 1, 1, 2, 2, 3)
+		data, finished, err := service.ReplayGIF(ctx, game.ID, models.ReplayGIFRequest{Delay: 200})
+		if err != nil {
+			t.Fatalf("failed to render: %v", err)
+		}
+		out, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("failed to decode: %v", err)
+		}
+		if !finished || len(out.Image) != 8 || out.Delay[0] != 20 || out.Delay[7] != 300 {
+			t.Errorf("expected 8 frames of a finished game delayed 20 then 300, got %d frames delayed %v", len(out.Image), out.Delay)
+		}
+		if cached() != 1 {
+			t.Fatalf("expected the GIF to be cached")
+		}
+
+		again, _, err := service.ReplayGIF(ctx, game.ID, models.ReplayGIFRequest{Delay: 200})
+		if err != nil || !bytes.Equal(again, data) || cached() != 1 {
+			t.Errorf("expected the cached GIF to be served, got error %v", err)
+		}
+	})
+
+	t.Run("delays are snapped to the replay speeds", func(t *testing.T) {
+		before := cached()
+		game := play(0, 0, 1, 1, 2, 2, 3)
+		for _, delay := range []int{0, 790, 810, 1000} {
+			data, _, err := service.ReplayGIF(ctx, game.ID, models.ReplayGIFRequest{Delay: delay})
+			if err != nil {
+				t.Fatalf("failed to render with delay %d: %v", delay, err)
+			}
+			if out, err := gif.DecodeAll(bytes.NewReader(data)); err != nil || out.Delay[0] != 80 {
+				t.Errorf("expected a delay of %d to be snapped to 80, got error %v", delay, err)
+			}
+		}
+		if cached() != before+1 {
+			t.Errorf("expected one GIF to be cached for every snapped delay, got %d", cached()-before)
+		}
+	})
+
+	t.Run("the cache is bounded", func(t *testing.T) {
+		service.replayCacheLimit = 2
+		defer func() { service.replayCacheLimit = maxCachedGIFs }()
+		game := play(0, 0, 1, 1, 2, 2, 3)
+		for _, delay := range models.ReplaySpeeds {
+			if _, _, err := service.ReplayGIF(ctx, game.ID, models.ReplayGIFRequest{Delay: delay}); err != nil {
+				t.Fatalf("failed to render with delay %d: %v", delay, err)
+			}
+		}
+		if cached() != 2 {
+			t.Errorf("expected the cache to hold 2 GIFs, got %d", cached())
+		}
+	})
+
+	t.Run("unfinished games are not cached", func(t *testing.T) {
+		before := cached()
+		game := play(3, 3)
+		if _, finished, err := service.ReplayGIF(ctx, game.ID, models.ReplayGIFRequest{}); err != nil || finished {
+			t.Fatalf("expected an unfinished game, got finished %t error %v", finished, err)
+		}
+		if cached() != before {
+			t.Errorf("expected the unfinished game not to be cached")
+		}
+	})
+}
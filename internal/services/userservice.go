@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/models"
+	"github.com/Zach51920/connect-four/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	minPasswordLength    = 8
+	maxPasswordLength    = 72 // bcrypt ignores anything longer
+	maxDisplayNameLength = 24
+)
+
+var (
+	ErrInvalidUsername    = errors.New("usernames must be 3-20 letters, numbers, dashes or underscores")
+	ErrInvalidPassword    = errors.New("passwords must be 8-72 characters")
+	ErrInvalidDisplayName = errors.New("display names must be 1-24 characters")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+
+	usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{3,20}$`)
+	dummyHash       = sync.OnceValue(func() []byte {
+		hash, _ := bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+		return hash
+	})
+)
+
+type UserService struct {
+	repository repository.Repository
+}
+
+func NewUserService(repo repository.Repository) *UserService {
+	return &UserService{repository: repo}
+}
+
+func (s *UserService) Register(ctx context.Context, req models.RegisterRequest) (*repository.User, error) {
+	if !usernamePattern.MatchString(req.Username) {
+		return nil, ErrInvalidUsername
+	}
+	if len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength {
+		return nil, ErrInvalidPassword
+	}
+	displayName := strings.TrimSpace(req.DisplayName)
+	if displayName == "" {
+		displayName = req.Username
+	}
+	if len(displayName) > maxDisplayNameLength {
+		return nil, ErrInvalidDisplayName
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &repository.User{
+		ID:           uuid.NewString(),
+		Username:     req.Username,
+		PasswordHash: string(hash),
+		DisplayName:  displayName,
+		Color:        models.ColorRed,
+		CreatedAt:    time.Now(),
+	}
+	if err = s.repository.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *UserService) Login(ctx context.Context, req models.LoginRequest) (*repository.User, error) {
+	user, err := s.repository.GetUserByUsername(ctx, req.Username)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		// still compare a hash so response times don't reveal which usernames exist
+		_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(req.Password))
+		return nil, ErrInvalidCredentials
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+func (s *UserService) GetUser(ctx context.Context, id string) (*repository.User, error) {
+	return s.repository.GetUser(ctx, id)
+}
+
+func (s *UserService) UpdateProfile(ctx context.Context, id string, req models.ProfileRequest) (*repository.User, error) {
+	user, err := s.repository.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	displayName := strings.TrimSpace(req.DisplayName)
+	if displayName == "" || len(displayName) > maxDisplayNameLength {
+		return nil, ErrInvalidDisplayName
+	}
+	user.DisplayName = displayName
+	if slices.Contains(models.Colors, req.Color) {
+		user.Color = req.Color
+	}
+
+	if err = s.repository.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -1,49 +1,224 @@
 package sessions
 
 import (
+	"context"
 	"fmt"
 	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/metrics"
+	"github.com/Zach51920/connect-four/internal/models"
+	"github.com/Zach51920/connect-four/internal/tracing"
 	views "github.com/Zach51920/connect-four/internal/views"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"log/slog"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Session struct {
-	ID       string
-	Game     *connectfour.Game
-	LastUsed time.Time
+	ID string
+	// lastUsed is when the session was last active in unix nanoseconds, it's touched by streams
+	// and the bot runner while the store prunes idle sessions.
+	lastUsed atomic.Int64
 
-	refreshCh   chan bool
-	shutdownCh  chan struct{}
-	isStreaming bool
+	// seat is swapped whenever the session starts another game, while streams, the bot runner
+	// and the opponent's session read it.
+	seat atomic.Pointer[seat]
+
+	// showEvaluation turns on the evaluation bar, evaluationKey is the position it was last
+	// sent for so it's only searched again once the game changes.
+	showEvaluation atomic.Bool
+	evaluationKey  string
+
+	refreshCh chan bool
+	eventCh   chan Event
+
+	// closeStream stops the stream that's listening, it's set by Listen for as long as it
+	// runs and is nil otherwise. Closing can race the stream starting and stopping.
+	streamMu    sync.Mutex
+	closeStream context.CancelFunc
+}
+
+// seat is the game a session plays and who it plays as, it's never changed once set.
+type seat struct {
+	game     *connectfour.SharedGame
+	playerID string
+	opponent *Session
+}
+
+// Event is pushed to the client outside of the regular game refreshes. The browser stream
+// renders Data with its view, API clients get it as JSON.
+type Event struct {
+	Name string
+	Data any
+}
+
+func newSession(id string, game *connectfour.Game) *Session {
+	s := &Session{
+		ID:        id,
+		refreshCh: make(chan bool, 1),
+		eventCh:   make(chan Event, 16),
+	}
+	s.touch()
+	s.SetGame(game)
+	return s
+}
+
+// Game is shared with the bot runner and, in an online game, the opponent's session. It's
+// only changed through its commands, everything else reads snapshots.
+func (s *Session) Game() *connectfour.SharedGame {
+	return s.currentSeat().game
+}
+
+// PlayerID is the player this session moves for in an online game, if it's empty the session
+// controls every human player.
+func (s *Session) PlayerID() string {
+	return s.currentSeat().playerID
+}
+
+// Opponent is the other session in an online game.
+func (s *Session) Opponent() *Session {
+	return s.currentSeat().opponent
+}
+
+func (s *Session) currentSeat() *seat {
+	if seat := s.seat.Load(); seat != nil {
+		return seat
+	}
+	return &seat{}
 }
 
 func (s *Session) SetGame(game *connectfour.Game) {
-	s.Game = game
+	s.seat.Store(&seat{game: share(game)})
+}
+
+// SetOnlineGame joins the session to a game against another session.
+func (s *Session) SetOnlineGame(game *connectfour.SharedGame, playerID string, opponent *Session) {
+	s.seat.Store(&seat{game: game, playerID: playerID, opponent: opponent})
 }
 
+func share(game *connectfour.Game) *connectfour.SharedGame {
+	if game == nil {
+		return nil
+	}
+	return connectfour.NewSharedGame(game)
+}
+
+// LastUsed is when the session last sent the client anything.
+func (s *Session) LastUsed() time.Time {
+	return time.Unix(0, s.lastUsed.Load())
+}
+
+func (s *Session) touch() {
+	s.lastUsed.Store(time.Now().UnixNano())
+}
+
+// ShowEvaluation reports whether the evaluation bar is on, it's never on in online games.
+func (s *Session) ShowEvaluation() bool {
+	return s.showEvaluation.Load() && s.PlayerID() == ""
+}
+
+func (s *Session) SetShowEvaluation(show bool) {
+	s.showEvaluation.Store(show)
+}
+
+// Controls reports whether the session is allowed to move for the player.
+func (s *Session) Controls(player connectfour.Player) bool {
+	id := s.PlayerID()
+	return id == "" || id == player.ID()
+}
+
+// Refresh re-renders the game for the client, refreshes are dropped if one is already pending.
 func (s *Session) Refresh() {
-	s.refreshCh <- true
+	select {
+	case s.refreshCh <- true:
+	default:
+	}
+}
+
+// Send pushes an event to the client.
+func (s *Session) Send(name string, data any) {
+	s.touch()
+	select {
+	case s.eventCh <- Event{Name: name, Data: data}:
+	default:
+		slog.Warn("Dropping event, client isn't keeping up", "session_id", s.ID, "event", name)
+	}
 }
 
+// CloseStream stops the session's stream, it's safe to call whether or not one is open.
 func (s *Session) CloseStream() {
-	if s.isStreaming && s.shutdownCh != nil {
-		close(s.shutdownCh)
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+	if s.closeStream != nil {
+		s.closeStream()
 	}
 }
 
-func (s *Session) Stream(c *gin.Context) {
+// Streaming reports whether the session has a stream open.
+func (s *Session) Streaming() bool {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+	return s.closeStream != nil
+}
+
+// Listen calls onRefresh whenever the game changes and onEvent for every event until the
+// context is done or the stream is closed. A session only has one listener at a time.
+func (s *Session) Listen(ctx context.Context, onRefresh func(), onEvent func(Event)) {
 	// check if we're already streaming
-	if s.isStreaming {
+	s.streamMu.Lock()
+	if s.closeStream != nil {
+		s.streamMu.Unlock()
+		slog.Debug("Unable to start stream", "error", "client stream already exists")
+		return
+	}
+	closed, closeStream := context.WithCancel(context.Background())
+	s.closeStream = closeStream
+	s.streamMu.Unlock()
+	metrics.ActiveStreams.Inc()
+	defer func() {
+		s.streamMu.Lock()
+		s.closeStream = nil
+		s.streamMu.Unlock()
+		closeStream()
+		metrics.ActiveStreams.Dec()
+	}()
+
+	for {
+		select {
+		case <-closed.Done():
+			slog.Debug("Stream shutdown triggered")
+			// flush events sent before the stream was closed, like the restart notice
+			for {
+				select {
+				case e := <-s.eventCh:
+					onEvent(e)
+				default:
+					return
+				}
+			}
+		case <-ctx.Done():
+			slog.Debug("Client closed connection", "session_id", s.ID)
+			return
+		case <-s.refreshCh:
+			if s.Game() != nil {
+				s.touch()
+				onRefresh()
+			}
+		case e := <-s.eventCh:
+			onEvent(e)
+		}
+	}
+}
+
+func (s *Session) Stream(c *gin.Context) {
+	if s.Streaming() {
 		slog.Debug("Unable to start stream", "error", "client stream already exists")
 		return
 	}
-	s.isStreaming = true
-	s.refreshCh = make(chan bool)
-	s.shutdownCh = make(chan struct{})
-	defer func() { s.isStreaming = false }()
 
 	// let the client know our intentions
 	slog.Info("Starting SSE stream", "session_id", s.ID)
@@ -52,47 +227,64 @@ func (s *Session) Stream(c *gin.Context) {
 	c.Header("Connection", "keep-alive")
 	c.Header("Access-Control-Allow-Origin", "*")
 
-	closeCh := c.Writer.CloseNotify()
-
 	// send an initial message to confirm we're connected
 	if _, err := c.Writer.WriteString("event: connection\ndata: SSE connection established\n\n"); err != nil {
 		slog.Error("Error establishing SSE connection", "error", err)
 		return
 	}
+	c.Writer.Flush()
 	slog.Debug("SSE connection established", "session_id", s.ID)
 
-	for {
-		select {
-		case <-s.shutdownCh:
-			slog.Debug("Stream shutdown triggered")
+	s.Listen(c.Request.Context(), func() { s.render(c) }, func(e Event) {
+		data := new(strings.Builder)
+		if err := views.Event(e.Name, e.Data).Render(c.Request.Context(), data); err != nil {
+			slog.Error("Failed to render event", "event", e.Name, "error", err)
 			return
-		case <-closeCh:
-			slog.Debug("Client closed connection", "session_id", s.ID)
-			return
-		case <-s.refreshCh:
-			s.render(c)
 		}
-	}
+		if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", e.Name, data.String()); err != nil {
+			slog.Error("Failed to write event", "event", e.Name, "error", err)
+		}
+		c.Writer.Flush()
+	})
 }
 
 func (s *Session) render(c *gin.Context) {
-	s.LastUsed = time.Now()
 	slog.Debug("Refreshing game view", "session_id", s.ID)
 
-	boardComponent := views.ConnectFourBoard(s.Game, *s.Game.Board)
-	scoreComponent := views.ScoreCard(s.Game)
+	// streams stay open for the whole game, so each render gets its own trace linked to the stream
+	seat := s.currentSeat()
+	if seat.game == nil {
+		return
+	}
+	game := seat.game.Snapshot()
+	ctx, span := tracing.Tracer().Start(c.Request.Context(), "Session.render",
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(c.Request.Context())),
+		trace.WithAttributes(attribute.String("session.id", s.ID), attribute.String("game.id", game.ID)),
+	)
+	defer span.End()
+
+	boardComponent := views.ConnectFourBoard(game, *game.Board)
+	scoreComponent := views.ScoreCard(game)
+	historyComponent := views.MoveHistory(models.NewMoveHistory(game))
 
 	boardHTML := new(strings.Builder)
 	scoreHTML := new(strings.Builder)
+	historyHTML := new(strings.Builder)
 
-	if err := boardComponent.Render(c.Request.Context(), boardHTML); err != nil {
+	ctx = views.WithViewer(ctx, seat.playerID)
+	if err := boardComponent.Render(ctx, boardHTML); err != nil {
 		slog.Error("Failed to render board", "error", err)
 		return
 	}
-	if err := scoreComponent.Render(c.Request.Context(), scoreHTML); err != nil {
+	if err := scoreComponent.Render(ctx, scoreHTML); err != nil {
 		slog.Error("Failed to render score", "error", err)
 		return
 	}
+	if err := historyComponent.Render(ctx, historyHTML); err != nil {
+		slog.Error("Failed to render move history", "error", err)
+		return
+	}
 
 	if _, err := fmt.Fprintf(c.Writer, "event: board-update\ndata: %s\n\n", boardHTML.String()); err != nil {
 		slog.Error("Failed to write board-update", "error", err)
@@ -100,5 +292,29 @@ func (s *Session) render(c *gin.Context) {
 	if _, err := fmt.Fprintf(c.Writer, "event: score-update\ndata: %s\n\n", scoreHTML.String()); err != nil {
 		slog.Error("Failed to write score-update", "error", err)
 	}
+	if _, err := fmt.Fprintf(c.Writer, "event: history-update\ndata: %s\n\n", historyHTML.String()); err != nil {
+		slog.Error("Failed to write history-update", "error", err)
+	}
+	c.Writer.Flush()
+	s.renderEvaluation(ctx, c, game)
+}
+
+// renderEvaluation sends the evaluation bar once the board is out, the search can take a moment.
+func (s *Session) renderEvaluation(ctx context.Context, c *gin.Context, game *connectfour.Game) {
+	key := fmt.Sprintf("%s/%d/%d", game.ID, len(game.Moves), game.State)
+	if !s.ShowEvaluation() || key == s.evaluationKey {
+		return
+	}
+	s.evaluationKey = key
+
+	eval := models.NewEvaluation(game)
+	evalHTML := new(strings.Builder)
+	if err := views.EvaluationPanel(&eval).Render(ctx, evalHTML); err != nil {
+		slog.Error("Failed to render evaluation", "error", err)
+		return
+	}
+	if _, err := fmt.Fprintf(c.Writer, "event: eval-update\ndata: %s\n\n", evalHTML.String()); err != nil {
+		slog.Error("Failed to write eval-update", "error", err)
+	}
 	c.Writer.Flush()
 }
@@ -0,0 +1,117 @@
+package sessions
+
+import (
+	"context"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/gin-gonic/gin"
+)
+
+// TestSession_SwapGame starts other games while the stream renders, it's meant to be run with
+// -race.
+func TestSession_SwapGame(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sess := newSession("swapped", connectfour.NewGame(connectfour.NewHumanPlayerPair()))
+	opponent := newSession("opponent", nil)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest("GET", "/game/stream", nil)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 50 {
+			switch i % 3 {
+			case 0:
+				sess.SetGame(connectfour.NewGame(connectfour.NewHumanPlayerPair()))
+			case 1:
+				player1, player2 := connectfour.NewHumanPlayerPair()
+				sess.SetOnlineGame(connectfour.NewSharedGame(connectfour.NewGame(player1, player2)), player1.ID(), opponent)
+			default:
+				sess.SetGame(nil)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			sess.render(c)
+			_ = sess.Controls(connectfour.NewHumanPlayer("someone", 'X'))
+			_ = sess.ShowEvaluation()
+		}
+	}()
+	wg.Wait()
+
+	if !strings.Contains(recorder.Body.String(), "event: board-update") {
+		t.Errorf("expected the stream to render the board")
+	}
+}
+
+// TestSession_CloseStream closes the stream from several places at once, like a shutdown
+// racing a new game, it's meant to be run with -race.
+func TestSession_CloseStream(t *testing.T) {
+	sess := newSession("closed", nil)
+	sess.CloseStream() // nothing is streaming yet
+
+	for range 20 {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			sess.Listen(context.Background(), func() {}, func(Event) {})
+		}()
+		for !sess.Streaming() {
+			runtime.Gosched()
+		}
+
+		var wg sync.WaitGroup
+		for range 3 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sess.CloseStream()
+			}()
+		}
+		wg.Wait()
+		<-done
+	}
+	if sess.Streaming() {
+		t.Errorf("expected the stream to be closed")
+	}
+}
+
+// TestMemorySessionStore_Prune prunes while sessions are in use, it's meant to be run with -race.
+func TestMemorySessionStore_Prune(t *testing.T) {
+	store := NewMemorySessionStore()
+	defer store.Close()
+	idle, active := store.New("idle", nil), store.New("active", nil)
+	idle.lastUsed.Store(time.Now().Add(-2 * MaxIdleTimeout).UnixNano())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			active.Send("ping", nil)
+			<-active.eventCh
+		}
+	}()
+	for range 50 {
+		store.prune()
+	}
+	wg.Wait()
+
+	if _, ok := store.get(idle.ID); ok {
+		t.Errorf("expected the idle session to be pruned")
+	}
+	if _, ok := store.get(active.ID); !ok {
+		t.Errorf("expected the active session to be kept")
+	}
+}
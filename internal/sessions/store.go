@@ -2,6 +2,7 @@ package sessions
 
 import (
 	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/metrics"
 	"log/slog"
 	"sync"
 	"time"
@@ -22,6 +23,10 @@ type MemorySessionStore struct {
 	sessionMu sync.RWMutex
 	sessions  map[string]*Session
 
+	// restore rebuilds sessions that aren't in memory, like the ones suspended by a restart
+	restoreMu sync.Mutex
+	restore   func(id string) (*connectfour.Game, bool)
+
 	shutdownOnce sync.Once
 	shutdownCh   chan struct{}
 }
@@ -36,20 +41,59 @@ func NewMemorySessionStore() *MemorySessionStore {
 }
 
 func (s *MemorySessionStore) New(id string, game *connectfour.Game) *Session {
-	session := &Session{ID: id, Game: game, LastUsed: time.Now()}
+	session := newSession(id, game)
 	s.sessionMu.Lock()
 	defer s.sessionMu.Unlock()
+	if _, ok := s.sessions[id]; !ok {
+		metrics.ActiveSessions.Inc()
+	}
 	s.sessions[id] = session
 	return session
 }
 
 func (s *MemorySessionStore) Get(id string) (*Session, bool) {
+	if sess, ok := s.get(id); ok || s.restore == nil {
+		return sess, ok
+	}
+
+	// a page load and its stream arrive together, only one of them gets to restore the game
+	s.restoreMu.Lock()
+	defer s.restoreMu.Unlock()
+	if sess, ok := s.get(id); ok {
+		return sess, ok
+	}
+	game, ok := s.restore(id)
+	if !ok {
+		return nil, false
+	}
+	slog.Info("Restored session", "session_id", id, "game_id", game.ID)
+	return s.New(id, game), true
+}
+
+func (s *MemorySessionStore) get(id string) (*Session, bool) {
 	s.sessionMu.RLock()
 	defer s.sessionMu.RUnlock()
 	sess, ok := s.sessions[id]
 	return sess, ok
 }
 
+// SetRestore sets how sessions missing from memory are rebuilt. It must be called before the
+// store is used.
+func (s *MemorySessionStore) SetRestore(restore func(id string) (*connectfour.Game, bool)) {
+	s.restore = restore
+}
+
+// All returns every session in the store.
+func (s *MemorySessionStore) All() []*Session {
+	s.sessionMu.RLock()
+	defer s.sessionMu.RUnlock()
+	all := make([]*Session, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		all = append(all, sess)
+	}
+	return all
+}
+
 func (s *MemorySessionStore) Close() {
 	s.shutdownOnce.Do(func() {
 		close(s.shutdownCh)
@@ -75,10 +119,11 @@ func (s *MemorySessionStore) prune() {
 	defer s.sessionMu.Unlock()
 
 	for id, sess := range s.sessions {
-		if time.Since(sess.LastUsed) > MaxIdleTimeout {
+		if time.Since(sess.LastUsed()) > MaxIdleTimeout {
 			slog.Debug("Removing stale session", "session_id", id)
 			sess.CloseStream()
 			delete(s.sessions, id)
+			metrics.ActiveSessions.Dec()
 		}
 	}
 }
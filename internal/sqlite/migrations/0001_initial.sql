@@ -0,0 +1,26 @@
+CREATE TABLE games (
+    id         TEXT PRIMARY KEY,
+    winner_id  TEXT,
+    move_count INTEGER  NOT NULL DEFAULT 0,
+    timestamp  DATETIME NOT NULL
+);
+
+CREATE TABLE players (
+    game_id  TEXT    NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+    seat     INTEGER NOT NULL,
+    id       TEXT    NOT NULL,
+    strategy TEXT    NOT NULL,
+    token    INTEGER NOT NULL,
+    score    INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (game_id, seat)
+);
+
+CREATE INDEX idx_players_id ON players (id);
+
+CREATE TABLE moves (
+    game_id   TEXT    NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+    id        INTEGER NOT NULL,
+    "column"  INTEGER NOT NULL,
+    player_id TEXT    NOT NULL,
+    PRIMARY KEY (game_id, id)
+);
@@ -0,0 +1 @@
+ALTER TABLE players ADD COLUMN name TEXT NOT NULL DEFAULT '';
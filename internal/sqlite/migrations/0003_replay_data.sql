@@ -0,0 +1,11 @@
+ALTER TABLE games ADD COLUMN variant TEXT NOT NULL DEFAULT 'standard';
+ALTER TABLE games ADD COLUMN rows INTEGER NOT NULL DEFAULT 6;
+ALTER TABLE games ADD COLUMN cols INTEGER NOT NULL DEFAULT 7;
+
+ALTER TABLE players ADD COLUMN bot_difficulty INTEGER;
+ALTER TABLE players ADD COLUMN bot_mistake_frequency INTEGER;
+ALTER TABLE players ADD COLUMN bot_randomize BOOLEAN;
+ALTER TABLE players ADD COLUMN bot_seed INTEGER;
+
+ALTER TABLE moves ADD COLUMN think_time_ms INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE moves ADD COLUMN score_delta INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,21 @@
+CREATE TABLE ratings (
+    subject_id TEXT PRIMARY KEY,
+    kind       TEXT     NOT NULL,
+    name       TEXT     NOT NULL,
+    rating     REAL     NOT NULL,
+    deviation  REAL     NOT NULL,
+    volatility REAL     NOT NULL,
+    games      INTEGER  NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE rating_history (
+    subject_id TEXT     NOT NULL REFERENCES ratings (subject_id) ON DELETE CASCADE,
+    game_id    TEXT     NOT NULL,
+    before     REAL     NOT NULL,
+    after      REAL     NOT NULL,
+    deviation  REAL     NOT NULL,
+    volatility REAL     NOT NULL,
+    timestamp  DATETIME NOT NULL,
+    PRIMARY KEY (subject_id, game_id)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE users (
+    id            TEXT PRIMARY KEY,
+    username      TEXT     NOT NULL UNIQUE COLLATE NOCASE,
+    password_hash TEXT     NOT NULL,
+    display_name  TEXT     NOT NULL,
+    color         TEXT     NOT NULL,
+    created_at    DATETIME NOT NULL
+);
+
+ALTER TABLE players ADD COLUMN user_id TEXT REFERENCES users (id);
+
+CREATE INDEX idx_players_user_id ON players (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE games ADD COLUMN result TEXT;
+ALTER TABLE games ADD COLUMN resigned_by TEXT;
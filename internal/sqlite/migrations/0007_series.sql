@@ -0,0 +1,20 @@
+CREATE TABLE series (
+    id         TEXT PRIMARY KEY,
+    best_of    INTEGER  NOT NULL,
+    player1_id TEXT     NOT NULL,
+    player2_id TEXT     NOT NULL,
+    winner_id  TEXT,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE series_games (
+    series_id   TEXT    NOT NULL REFERENCES series (id) ON DELETE CASCADE,
+    game_number INTEGER NOT NULL,
+    game_id     TEXT    NOT NULL,
+    winner_id   TEXT,
+    PRIMARY KEY (series_id, game_number)
+);
+
+-- games are saved before their series, so there's no foreign key here
+ALTER TABLE games ADD COLUMN series_id TEXT;
+ALTER TABLE games ADD COLUMN series_game INTEGER;
@@ -0,0 +1,5 @@
+CREATE TABLE suspended_sessions (
+    id           TEXT PRIMARY KEY,
+    game_id      TEXT     NOT NULL,
+    suspended_at DATETIME NOT NULL
+);
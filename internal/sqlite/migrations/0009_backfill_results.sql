@@ -0,0 +1,3 @@
+-- games saved before results were stored were either won on the board or drawn on a full board
+UPDATE games SET result = 'connect_four' WHERE result IS NULL AND winner_id IS NOT NULL;
+UPDATE games SET result = 'board_full' WHERE result IS NULL AND move_count >= rows * cols;
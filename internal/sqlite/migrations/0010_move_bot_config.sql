@@ -0,0 +1,4 @@
+ALTER TABLE moves ADD COLUMN bot_difficulty INTEGER;
+ALTER TABLE moves ADD COLUMN bot_mistake_frequency INTEGER;
+ALTER TABLE moves ADD COLUMN bot_randomize BOOLEAN;
+ALTER TABLE moves ADD COLUMN bot_seed INTEGER;
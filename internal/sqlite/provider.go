@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const DefaultPath = "connect4.db"
+
+type Config struct {
+	Path string
+}
+
+type Provider struct {
+	config *Config
+	db     *sql.DB
+}
+
+func FromEnv() *Config {
+	path := os.Getenv("SQLITE_PATH")
+	if path == "" {
+		path = DefaultPath
+	}
+	return &Config{Path: path}
+}
+
+func NewProvider(config *Config) (*Provider, error) {
+	if config == nil {
+		return nil, errors.New("config is nil")
+	}
+
+	dsn := "file:" + config.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// sqlite only supports a single writer, serialize access through one connection
+	db.SetMaxOpenConns(1)
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	if err = Migrate(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	provider := &Provider{db: db, config: config}
+	return provider, nil
+}
+
+func (p *Provider) DB() *sql.DB {
+	return p.db
+}
+
+func (p *Provider) Ping() error {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), time.Second)
+	defer ctxCancel()
+
+	return p.db.PingContext(ctx)
+}
+
+func (p *Provider) Close() error {
+	return p.db.Close()
+}
@@ -0,0 +1,143 @@
+package tournament
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/engine"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ModeRoundRobin = "round_robin"
+	ModeGauntlet   = "gauntlet"
+)
+
+type Config struct {
+	Mode            string      `yaml:"mode"`
+	Gauntlet        string      `yaml:"gauntlet"`
+	GamesPerPairing int         `yaml:"games_per_pairing"`
+	Parallel        int         `yaml:"parallel"`
+	Openings        []string    `yaml:"openings"`
+	Bots            []BotConfig `yaml:"bots"`
+}
+
+type BotConfig struct {
+	Name             string `yaml:"name"`
+	Strategy         string `yaml:"strategy"`
+	Difficulty       int    `yaml:"difficulty"`
+	MistakeFrequency int    `yaml:"mistake_frequency"`
+	Randomize        bool   `yaml:"randomize"`
+	Seed             int64  `yaml:"seed"`
+
+	// Engine is the command line of an external engine to play instead of a built-in strategy,
+	// MoveTime is how long it gets to think about each move.
+	Engine   []string      `yaml:"engine"`
+	MoveTime time.Duration `yaml:"move_time"`
+}
+
+func Load(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tournament file: %w", err)
+	}
+
+	config := new(Config)
+	if err = yaml.Unmarshal(b, config); err != nil {
+		return nil, fmt.Errorf("failed to parse tournament file: %w", err)
+	}
+	return config, config.validate()
+}
+
+func (c *Config) validate() error {
+	c.Mode = strings.ToLower(c.Mode)
+	if c.Mode == "" {
+		c.Mode = ModeRoundRobin
+	}
+	if c.GamesPerPairing <= 0 {
+		c.GamesPerPairing = 1
+	}
+	if c.Parallel <= 0 {
+		c.Parallel = 1
+	}
+	if len(c.Bots) < 2 {
+		return errors.New("a tournament needs at least two bots")
+	}
+
+	names := make(map[string]bool, len(c.Bots))
+	for i, bot := range c.Bots {
+		if bot.Name == "" {
+			return fmt.Errorf("bot %d has no name", i)
+		}
+		if names[bot.Name] {
+			return fmt.Errorf("duplicate bot name: %s", bot.Name)
+		}
+		names[bot.Name] = true
+		if len(bot.Engine) > 0 {
+			if _, err := exec.LookPath(bot.Engine[0]); err != nil {
+				return fmt.Errorf("bot %s: %w", bot.Name, err)
+			}
+			continue
+		}
+		if c.Bots[i].Strategy == "" {
+			c.Bots[i].Strategy = connectfour.StrategyMinimax
+		}
+		if _, err := connectfour.NewStrategy(c.Bots[i].Strategy, bot.config(0)); err != nil {
+			return fmt.Errorf("bot %s: %w", bot.Name, err)
+		}
+	}
+
+	switch c.Mode {
+	case ModeRoundRobin:
+	case ModeGauntlet:
+		if !names[c.Gauntlet] {
+			return fmt.Errorf("gauntlet bot %q is not defined", c.Gauntlet)
+		}
+	default:
+		return fmt.Errorf("unknown tournament mode: %s", c.Mode)
+	}
+
+	for _, opening := range c.Openings {
+		if _, err := connectfour.ParseColumns(opening); err != nil {
+			return fmt.Errorf("invalid opening %q: %w", opening, err)
+		}
+	}
+	return nil
+}
+
+// config builds the bot config for a single game, the seed is offset by the game number so
+// repeated pairings don't replay the exact same game.
+func (b BotConfig) config(game int) *connectfour.Config {
+	return &connectfour.Config{
+		Difficulty:       b.Difficulty,
+		MistakeFrequency: b.MistakeFrequency,
+		Randomize:        b.Randomize,
+		Seed:             b.Seed + int64(game),
+	}
+}
+
+// newPlayer creates the bot for a single game, close must be called once the game is over.
+func (b BotConfig) newPlayer(token rune, game int) (player *connectfour.BotPlayer, close func(), err error) {
+	config := b.config(game)
+	if len(b.Engine) > 0 {
+		eng, err := engine.Start(config, b.Engine[0], b.Engine[1:]...)
+		if err != nil {
+			return nil, nil, err
+		}
+		if b.MoveTime > 0 {
+			eng.SetMoveTime(b.MoveTime)
+		}
+		return connectfour.NewBotPlayer(b.Name, token, config, eng), func() { _ = eng.Close() }, nil
+	}
+
+	strategy, err := connectfour.NewStrategy(b.Strategy, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return connectfour.NewBotPlayer(b.Name, token, config, strategy), func() {}, nil
+}
@@ -0,0 +1,189 @@
+package tournament
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/ratings"
+)
+
+type Score struct {
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+func (s *Score) add(won, lost bool) {
+	switch {
+	case won:
+		s.Wins++
+	case lost:
+		s.Losses++
+	default:
+		s.Draws++
+	}
+}
+
+func (s Score) Games() int { return s.Wins + s.Draws + s.Losses }
+
+func (s Score) Points() float64 { return float64(s.Wins) + float64(s.Draws)/2 }
+
+type Standing struct {
+	Name   string
+	Score  Score
+	Rating ratings.Rating
+	Moves  int
+	Think  time.Duration
+}
+
+// AvgMoveTime is the average think time over every move the bot chose itself.
+func (s Standing) AvgMoveTime() time.Duration {
+	if s.Moves == 0 {
+		return 0
+	}
+	return s.Think / time.Duration(s.Moves)
+}
+
+type Report struct {
+	Standings []*Standing
+	// Pairings is keyed by bot name then opponent name, scores are from the first bots perspective.
+	Pairings map[string]map[string]*Score
+	Aborted  int
+}
+
+func NewReport(config *Config, records []GameRecord) *Report {
+	report := &Report{Pairings: make(map[string]map[string]*Score)}
+	standings := make(map[string]*Standing, len(config.Bots))
+	for _, bot := range config.Bots {
+		// everyone starts level so the final ratings can be used to calibrate bot difficulties
+		standing := &Standing{Name: bot.Name, Rating: ratings.NewRating()}
+		standings[bot.Name] = standing
+		report.Standings = append(report.Standings, standing)
+		report.Pairings[bot.Name] = make(map[string]*Score)
+	}
+
+	for _, record := range records {
+		if record.Result == ResultAborted {
+			report.Aborted++
+			continue
+		}
+		red, yellow := record.Red.Name, record.Yellow.Name
+		redWon, yellowWon := record.Result == ResultRedWin, record.Result == ResultYellowWin
+		standings[red].Score.add(redWon, yellowWon)
+		standings[yellow].Score.add(yellowWon, redWon)
+		report.pairing(red, yellow).add(redWon, yellowWon)
+		report.pairing(yellow, red).add(yellowWon, redWon)
+
+		redScore := ratings.ScoreDraw
+		if redWon {
+			redScore = ratings.ScoreWin
+		} else if yellowWon {
+			redScore = ratings.ScoreLoss
+		}
+		redRating, yellowRating := standings[red].Rating, standings[yellow].Rating
+		standings[red].Rating = ratings.Update(redRating, yellowRating, redScore)
+		standings[yellow].Rating = ratings.Update(yellowRating, redRating, 1-redScore)
+
+		opening, _ := connectfour.ParseColumns(record.Opening)
+		for i, move := range record.Moves {
+			if i < len(opening) {
+				continue
+			}
+			standing := standings[red]
+			if i%2 == 1 {
+				standing = standings[yellow]
+			}
+			standing.Moves++
+			standing.Think += move.ThinkTime
+		}
+	}
+
+	sort.SliceStable(report.Standings, func(i, j int) bool {
+		return report.Standings[i].Score.Points() > report.Standings[j].Score.Points()
+	})
+	return report
+}
+
+func (r *Report) pairing(bot, opponent string) *Score {
+	score, ok := r.Pairings[bot][opponent]
+	if !ok {
+		score = new(Score)
+		r.Pairings[bot][opponent] = score
+	}
+	return score
+}
+
+// WriteTables writes the standings and the win/draw/loss table for every pairing.
+func (r *Report) WriteTables(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "#\tBot\tGames\tW\tD\tL\tPoints\tRating\tAvg move\t")
+	for i, s := range r.Standings {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%d\t%.1f\t%.0f ±%.0f\t%s\t\n", i+1, s.Name, s.Score.Games(),
+			s.Score.Wins, s.Score.Draws, s.Score.Losses, s.Score.Points(), s.Rating.Rating, 2*s.Rating.Deviation,
+			s.AvgMoveTime().Round(time.Microsecond))
+	}
+	fmt.Fprintln(tw)
+
+	// cross table, each cell is W-D-L for the row bot against the column bot
+	header := []string{"W-D-L"}
+	for _, s := range r.Standings {
+		header = append(header, s.Name)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t")+"\t")
+	for _, row := range r.Standings {
+		cells := []string{row.Name}
+		for _, col := range r.Standings {
+			score, ok := r.Pairings[row.Name][col.Name]
+			if !ok {
+				cells = append(cells, "-")
+				continue
+			}
+			cells = append(cells, fmt.Sprintf("%d-%d-%d", score.Wins, score.Draws, score.Losses))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t")+"\t")
+	}
+	if r.Aborted > 0 {
+		fmt.Fprintf(tw, "\n%d games aborted\t\n", r.Aborted)
+	}
+	return tw.Flush()
+}
+
+// WriteLog writes every game in a PGN-like format, moves are in column notation with the
+// think time as a comment.
+func WriteLog(w io.Writer, records []GameRecord) error {
+	for _, record := range records {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "[Event \"connect-four tournament\"]\n")
+		fmt.Fprintf(&sb, "[Game \"%d\"]\n", record.Number)
+		fmt.Fprintf(&sb, "[Round \"%d\"]\n", record.Round)
+		fmt.Fprintf(&sb, "[Red \"%s\"]\n", record.Red.Name)
+		fmt.Fprintf(&sb, "[Yellow \"%s\"]\n", record.Yellow.Name)
+		if record.Opening != "" {
+			fmt.Fprintf(&sb, "[Opening \"%s\"]\n", record.Opening)
+		}
+		fmt.Fprintf(&sb, "[Result \"%s\"]\n", record.Result)
+		fmt.Fprintf(&sb, "[Duration \"%s\"]\n", record.Duration.Round(time.Millisecond))
+		if record.Err != nil {
+			fmt.Fprintf(&sb, "[Error \"%s\"]\n", record.Err)
+		}
+		sb.WriteString("\n")
+
+		for i, move := range record.Moves {
+			if i%2 == 0 {
+				fmt.Fprintf(&sb, "%d. ", i/2+1)
+			}
+			fmt.Fprintf(&sb, "%s {%s} ", connectfour.ColumnName(move.Column), move.ThinkTime.Round(time.Microsecond))
+		}
+		fmt.Fprintf(&sb, "%s\n\n", record.Result)
+
+		if _, err := io.WriteString(w, sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
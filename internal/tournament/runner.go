@@ -0,0 +1,189 @@
+package tournament
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+type Result int
+
+const (
+	ResultDraw Result = iota
+	ResultRedWin
+	ResultYellowWin
+	ResultAborted
+)
+
+func (r Result) String() string {
+	switch r {
+	case ResultRedWin:
+		return "1-0"
+	case ResultYellowWin:
+		return "0-1"
+	case ResultDraw:
+		return "1/2-1/2"
+	default:
+		return "*"
+	}
+}
+
+// Match is a single scheduled game, Red always moves first.
+type Match struct {
+	Number  int
+	Round   int
+	Red     BotConfig
+	Yellow  BotConfig
+	Opening string
+}
+
+type GameRecord struct {
+	Match
+	Result   Result
+	Moves    []connectfour.Move
+	Duration time.Duration
+	Err      error
+}
+
+// Schedule lists every game of the tournament. Each pairing plays every opening twice with
+// the colours swapped, GamesPerPairing times over.
+func (c *Config) Schedule() []Match {
+	var pairings [][2]BotConfig
+	switch c.Mode {
+	case ModeGauntlet:
+		var champion BotConfig
+		for _, bot := range c.Bots {
+			if bot.Name == c.Gauntlet {
+				champion = bot
+			}
+		}
+		for _, bot := range c.Bots {
+			if bot.Name != c.Gauntlet {
+				pairings = append(pairings, [2]BotConfig{champion, bot})
+			}
+		}
+	default:
+		for i := range c.Bots {
+			for j := i + 1; j < len(c.Bots); j++ {
+				pairings = append(pairings, [2]BotConfig{c.Bots[i], c.Bots[j]})
+			}
+		}
+	}
+
+	openings := c.Openings
+	if len(openings) == 0 {
+		openings = []string{""}
+	}
+
+	var matches []Match
+	for round := 1; round <= c.GamesPerPairing; round++ {
+		for _, pairing := range pairings {
+			for _, opening := range openings {
+				for _, colours := range [][2]BotConfig{{pairing[0], pairing[1]}, {pairing[1], pairing[0]}} {
+					matches = append(matches, Match{
+						Number:  len(matches) + 1,
+						Round:   round,
+						Red:     colours[0],
+						Yellow:  colours[1],
+						Opening: opening,
+					})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// Run plays every scheduled game using the configured number of workers. Records are returned
+// in schedule order. Cancelling the context aborts the games that haven't finished.
+func Run(ctx context.Context, config *Config, onResult func(GameRecord)) []GameRecord {
+	matches := config.Schedule()
+	records := make([]GameRecord, len(matches))
+
+	jobs := make(chan Match)
+	var wg sync.WaitGroup
+	var resultMu sync.Mutex
+	for range config.Parallel {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for match := range jobs {
+				record := Play(ctx, match)
+				records[match.Number-1] = record
+				if onResult != nil {
+					resultMu.Lock()
+					onResult(record)
+					resultMu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, match := range matches {
+		jobs <- match
+	}
+	close(jobs)
+	wg.Wait()
+	return records
+}
+
+// Play plays a single match to completion.
+func Play(ctx context.Context, match Match) (record GameRecord) {
+	record = GameRecord{Match: match, Result: ResultAborted}
+	start := time.Now()
+	defer func() { record.Duration = time.Since(start) }()
+
+	red, closeRed, err := match.Red.newPlayer('X', match.Number)
+	if err != nil {
+		record.Err = err
+		return record
+	}
+	defer closeRed()
+	yellow, closeYellow, err := match.Yellow.newPlayer('O', match.Number)
+	if err != nil {
+		record.Err = err
+		return record
+	}
+	defer closeYellow()
+	game := connectfour.NewGame(red, yellow)
+	game.RefreshState()
+
+	// play out the opening before the bots take over
+	opening, _ := connectfour.ParseColumns(match.Opening)
+	for _, col := range opening {
+		if _, err = game.Play(game.CurrentPlayer(), col); err != nil {
+			record.Err = fmt.Errorf("invalid opening %q: %w", match.Opening, err)
+			return record
+		}
+		game.NextPlayer()
+	}
+
+	for game.InProgress() {
+		if err = ctx.Err(); err != nil {
+			record.Err = err
+			record.Moves = game.Moves
+			return record
+		}
+		bot := game.CurrentPlayer().(*connectfour.BotPlayer)
+		if _, err = game.Play(bot, bot.EvaluateGame(ctx, game)); err != nil {
+			record.Err = fmt.Errorf("%s made an invalid move: %w", bot.Name(), err)
+			record.Moves = game.Moves
+			return record
+		}
+		game.NextPlayer()
+	}
+
+	record.Moves = game.Moves
+	switch {
+	case game.State == connectfour.GameStateDraw:
+		record.Result = ResultDraw
+	case game.Winner == connectfour.Player(red):
+		record.Result = ResultRedWin
+	default:
+		record.Result = ResultYellowWin
+	}
+	return record
+}
@@ -0,0 +1,103 @@
+package tournament
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func testConfig(mode string) *Config {
+	return &Config{
+		Mode:            mode,
+		Gauntlet:        "a",
+		GamesPerPairing: 2,
+		Parallel:        2,
+		Openings:        []string{"d", "cd"},
+		Bots: []BotConfig{
+			{Name: "a", Difficulty: 1, Seed: 1},
+			{Name: "b", Difficulty: 2, Seed: 2},
+			{Name: "c", Difficulty: 1, Randomize: true, Seed: 3},
+		},
+	}
+}
+
+func TestConfig_Schedule(t *testing.T) {
+	tests := []struct {
+		mode  string
+		games int
+	}{
+		// 3 pairings * 2 openings * 2 colours * 2 games per pairing
+		{ModeRoundRobin, 24},
+		// 2 pairings * 2 openings * 2 colours * 2 games per pairing
+		{ModeGauntlet, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			config := testConfig(tt.mode)
+			if err := config.validate(); err != nil {
+				t.Fatalf("invalid config: %v", err)
+			}
+			matches := config.Schedule()
+			if len(matches) != tt.games {
+				t.Fatalf("expected %d games, got %d", tt.games, len(matches))
+			}
+
+			// every game should have a twin with the colours swapped
+			colours := make(map[[4]string]int)
+			for _, m := range matches {
+				colours[[4]string{m.Red.Name, m.Yellow.Name, m.Opening}]++
+			}
+			for key, count := range colours {
+				if swapped := colours[[4]string{key[1], key[0], key[2]}]; swapped != count {
+					t.Errorf("%s vs %s with opening %q played %d times as red but %d times as yellow", key[0], key[1], key[2], count, swapped)
+				}
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	config := testConfig(ModeRoundRobin)
+	if err := config.validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	records := Run(context.Background(), config, nil)
+	again := Run(context.Background(), config, nil)
+	for i, record := range records {
+		if record.Err != nil || record.Result == ResultAborted {
+			t.Fatalf("game %d failed: %v", record.Number, record.Err)
+		}
+		if record.Result != again[i].Result || len(record.Moves) != len(again[i].Moves) {
+			t.Errorf("game %d was not reproducible", record.Number)
+		}
+	}
+
+	report := NewReport(config, records)
+	games := 0
+	for _, s := range report.Standings {
+		games += s.Score.Games()
+	}
+	if games != 2*len(records) {
+		t.Errorf("expected standings to count %d games, got %d", 2*len(records), games)
+	}
+}
+
+// TestConfigs loads the tournament configs shipped in configs.
+func TestConfigs(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "..", "configs", "tournament*.yaml"))
+	if err != nil {
+		t.Fatalf("failed to list configs: %v", err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("expected to find tournament configs")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			if _, err := Load(path); err != nil {
+				t.Errorf("failed to load tournament config: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Package tracing sets up OpenTelemetry tracing and holds the tracer the rest of the app uses.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+
+	ServiceName = "connect-four"
+	tracerName  = "github.com/Zach51920/connect-four"
+)
+
+type Config struct {
+	// Exporter is where spans are sent: otlp, stdout or none.
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the OTLP/HTTP collector address, e.g. localhost:4318. When empty the standard
+	// OTEL_EXPORTER_OTLP_ENDPOINT environment variable is used.
+	Endpoint string `yaml:"endpoint"`
+	// Insecure sends spans to the collector over plain HTTP.
+	Insecure bool `yaml:"insecure"`
+	// SampleRatio is the fraction of traces kept, everything is kept when it's zero.
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// Init installs the global tracer provider. The returned function flushes any buffered spans
+// and must be called before exiting.
+func Init(ctx context.Context, cfg *Config) (shutdown func(context.Context) error, err error) {
+	noop := func(context.Context) error { return nil }
+	if cfg == nil || cfg.Exporter == "" || cfg.Exporter == ExporterNone {
+		return noop, nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	default:
+		return noop, fmt.Errorf("unknown trace exporter: %s", cfg.Exporter)
+	}
+	if err != nil {
+		return noop, fmt.Errorf("failed to create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 {
+		sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the app's tracer, spans are dropped until Init has installed a provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// End ends the span, marking it as failed if err points at an error. Deferred at the top of a
+// traced function, err must point at its named error result.
+func End(span trace.Span, err *error) {
+	if err != nil && *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
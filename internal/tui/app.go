@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+type Key int
+
+const (
+	KeyRune Key = iota
+	KeyLeft
+	KeyRight
+	KeyDown
+	KeyEnter
+	KeyInterrupt
+)
+
+type keyPress struct {
+	key Key
+	r   rune
+}
+
+// readKeys decodes key presses from a terminal in raw mode until the reader fails.
+func readKeys(r io.Reader, keys chan<- keyPress) {
+	defer close(keys)
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return
+		}
+		switch b {
+		case '\r', '\n', ' ':
+			keys <- keyPress{key: KeyEnter}
+		case 3: // ctrl+c
+			keys <- keyPress{key: KeyInterrupt}
+		case 0x1b:
+			// arrow keys are sent as ESC [ A-D
+			if next, _ := br.Peek(2); len(next) == 2 && next[0] == '[' {
+				_, _ = br.Discard(2)
+				switch next[1] {
+				case 'C':
+					keys <- keyPress{key: KeyRight}
+				case 'D':
+					keys <- keyPress{key: KeyLeft}
+				case 'B':
+					keys <- keyPress{key: KeyDown}
+				}
+			}
+		default:
+			keys <- keyPress{key: KeyRune, r: rune(b)}
+		}
+	}
+}
+
+// Run draws the game and handles key presses until the player quits or the context is done.
+func Run(ctx context.Context, game Game, in io.Reader, out io.Writer, online bool) error {
+	keys := make(chan keyPress)
+	go readKeys(in, keys)
+
+	view := View{Cursor: 3, Hint: -1, Online: online}
+	for {
+		view.State, view.HasState = game.State()
+		view.PlayerID = game.PlayerID()
+		view.Status = game.Status()
+		if view.HasState {
+			view.Cursor = min(view.Cursor, len(view.State.Board[0])-1)
+		}
+		if err := Render(out, view); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-game.Updates():
+			view.Hint = -1
+		case press, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			view.Message = ""
+			quit, err := handleKey(game, &view, press)
+			if quit {
+				return nil
+			}
+			if err != nil {
+				view.Message = err.Error()
+			}
+		}
+	}
+}
+
+var errNoGame = errors.New("there's no game yet")
+
+// handleKey applies a key press to the game, it reports whether the player wants to quit.
+func handleKey(game Game, view *View, press keyPress) (bool, error) {
+	drop := func(col int) error {
+		view.Hint = -1
+		return game.Play(col)
+	}
+
+	switch {
+	case press.key == KeyInterrupt || press.r == 'q':
+		return true, nil
+	case !view.HasState && press.r != 'n':
+		return false, errNoGame
+	case press.key == KeyLeft:
+		view.Cursor = max(view.Cursor-1, 0)
+	case press.key == KeyRight:
+		view.Cursor++
+	case press.key == KeyEnter || press.key == KeyDown:
+		return false, drop(view.Cursor)
+	case press.r >= '1' && press.r <= '9':
+		col, _ := strconv.Atoi(string(press.r))
+		view.Cursor = col - 1
+		return false, drop(col - 1)
+	case press.r == 'h':
+		col, err := Hint(view.State)
+		if err != nil {
+			return false, err
+		}
+		view.Hint, view.Cursor = col, col
+		view.Message = "Hint: play " + connectfour.ColumnName(col)
+	case press.r == 'u':
+		view.Hint = -1
+		return false, game.Undo()
+	case press.r == 'r':
+		return false, game.Resign()
+	case press.r == 'n':
+		view.Hint = -1
+		return false, game.NewGame()
+	}
+	return false, nil
+}
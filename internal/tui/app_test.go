@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []keyPress
+	}{
+		{"arrows", "\x1b[D\x1b[C\x1b[B", []keyPress{{key: KeyLeft}, {key: KeyRight}, {key: KeyDown}}},
+		{"enter and space", "\r ", []keyPress{{key: KeyEnter}, {key: KeyEnter}}},
+		{"runes", "4h", []keyPress{{key: KeyRune, r: '4'}, {key: KeyRune, r: 'h'}}},
+		{"interrupt", "\x03", []keyPress{{key: KeyInterrupt}}},
+		{"unknown escape", "\x1b[Aq", []keyPress{{key: KeyRune, r: 'q'}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := make(chan keyPress)
+			go readKeys(strings.NewReader(tt.input), keys)
+			var got []keyPress
+			for press := range keys {
+				got = append(got, press)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("key %d: expected %v, got %v", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"errors"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/models"
+)
+
+var (
+	ErrNotYourTurn   = errors.New("it's not your turn")
+	ErrNothingToUndo = errors.New("there's nothing to undo")
+	ErrUndoOnline    = errors.New("moves can't be undone in online games")
+)
+
+// Game is the side of a game the terminal plays, either locally or through a server.
+type Game interface {
+	// State returns the game to draw, ok is false until there is one.
+	State() (state models.GameState, ok bool)
+	// PlayerID is the player the terminal moves for.
+	PlayerID() string
+	// Status describes what the game is doing outside of the board, like searching for an opponent.
+	Status() string
+	Play(col int) error
+	Undo() error
+	Resign() error
+	// NewGame starts another game once this one is over.
+	NewGame() error
+	// Updates signals the game changed without a key being pressed, like an opponent moving.
+	Updates() <-chan struct{}
+	Close() error
+}
+
+// hintConfig is used to suggest moves, it searches deep and never makes mistakes.
+func hintConfig() *connectfour.Config {
+	return connectfour.DefaultConfig().SetDifficulty(7).SetMistakeFrequency(0).IncludeRandomization(false)
+}
+
+// Hint suggests a column for the player to move in the game.
+func Hint(state models.GameState) (int, error) {
+	board, err := boardFromState(state)
+	if err != nil {
+		return -1, err
+	}
+	token := []rune(state.Players[0].Token)[0]
+	if state.Turn == state.Players[1].ID {
+		token = []rune(state.Players[1].Token)[0]
+	}
+	return connectfour.NewMinimaxStrat(hintConfig()).Suggest(board, token).Column, nil
+}
+
+// boardFromState rebuilds the board by replaying the move string, the first player always
+// moves first.
+func boardFromState(state models.GameState) (*connectfour.Board, error) {
+	cols, err := connectfour.ParseColumns(state.Moves)
+	if err != nil {
+		return nil, err
+	}
+	rows := len(state.Board)
+	width := connectfour.DefaultBoardColumns
+	if rows > 0 {
+		width = len(state.Board[0])
+	} else {
+		rows = connectfour.DefaultBoardRows
+	}
+
+	board := connectfour.NewBoard(rows, width)
+	tokens := [2]rune{[]rune(state.Players[0].Token)[0], []rune(state.Players[1].Token)[0]}
+	for i, col := range cols {
+		board.Insert(tokens[i%2], col)
+	}
+	return board, nil
+}
+
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
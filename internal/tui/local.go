@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/models"
+)
+
+// LocalGame plays against a bot in the same process.
+type LocalGame struct {
+	mu       sync.Mutex
+	game     *connectfour.Game
+	human    connectfour.Player
+	thinking bool
+	updates  chan struct{}
+}
+
+// NewLocalGame starts a game against a bot using the registered strategy. The human plays red
+// and moves first unless botFirst is set.
+func NewLocalGame(name, strategy string, config *connectfour.Config, botFirst bool) (*LocalGame, error) {
+	strat, err := connectfour.NewStrategy(strategy, config)
+	if err != nil {
+		return nil, err
+	}
+	human := connectfour.NewHumanPlayer(name, 'X')
+	bot := connectfour.NewBotPlayer(fmt.Sprintf("%s bot", strategy), 'O', config, strat)
+
+	game := connectfour.NewGame(human, bot)
+	if botFirst {
+		game = connectfour.NewGame(bot, human)
+	}
+	l := &LocalGame{game: game, human: human, updates: make(chan struct{}, 1)}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.startBot()
+	return l, nil
+}
+
+func (l *LocalGame) State() (models.GameState, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return models.NewGameState(l.game), true
+}
+
+func (l *LocalGame) PlayerID() string { return l.human.ID() }
+
+func (l *LocalGame) Status() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.thinking {
+		return l.game.CurrentPlayer().Name() + " is thinking..."
+	}
+	return ""
+}
+
+func (l *LocalGame) Play(col int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.thinking || l.game.CurrentPlayer() != l.human {
+		return ErrNotYourTurn
+	}
+	if _, err := l.game.Play(l.human, col); err != nil {
+		return err
+	}
+	l.game.NextPlayer()
+	l.startBot()
+	return nil
+}
+
+// Undo takes back the players last move along with the bots reply.
+func (l *LocalGame) Undo() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.thinking {
+		return ErrNotYourTurn
+	}
+
+	moves := l.game.Moves
+	last := len(moves) - 1
+	for last >= 0 && moves[last].PlayerID != l.human.ID() {
+		last--
+	}
+	if last < 0 {
+		return ErrNothingToUndo
+	}
+
+	game, err := connectfour.Replay(l.game.Setup(), moves[:last])
+	if err != nil {
+		return err
+	}
+	l.game = game
+	l.human, _ = game.FindPlayer(l.human.ID())
+	return nil
+}
+
+func (l *LocalGame) Resign() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.game.Resign(l.human)
+}
+
+// NewGame starts a rematch where the other player moves first.
+func (l *LocalGame) NewGame() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.game.Rematch(); err != nil {
+		return err
+	}
+	l.startBot()
+	return nil
+}
+
+func (l *LocalGame) Updates() <-chan struct{} { return l.updates }
+
+func (l *LocalGame) Close() error { return nil }
+
+// startBot lets the bot move in the background if it's their turn, the lock must be held.
+func (l *LocalGame) startBot() {
+	bot, ok := l.game.CurrentPlayer().(*connectfour.BotPlayer)
+	if !ok || !l.game.InProgress() {
+		return
+	}
+	l.thinking = true
+	game, snapshot := l.game, l.game.Snapshot()
+
+	go func() {
+		col := bot.EvaluateGame(context.Background(), snapshot)
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.thinking = false
+		if l.game != game {
+			return // the game was replaced while the bot was thinking
+		}
+		if _, err := game.Play(bot, col); err == nil {
+			game.NextPlayer()
+		}
+		notify(l.updates)
+	}()
+}
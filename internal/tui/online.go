@@ -0,0 +1,171 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/Zach51920/connect-four/internal/models"
+	"github.com/gorilla/websocket"
+)
+
+// OnlineGame plays through a server's game websocket, the server does all the matchmaking
+// and keeps the game state.
+type OnlineGame struct {
+	conn        *websocket.Conn
+	name        string
+	timeControl string
+	updates     chan struct{}
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	state    *models.GameState
+	playerID string
+	status   string
+}
+
+// DialOnline connects to the server and joins the matchmaking queue.
+func DialOnline(ctx context.Context, url, name, timeControl string) (*OnlineGame, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", url, err)
+	}
+
+	o := &OnlineGame{
+		conn:        conn,
+		name:        name,
+		timeControl: timeControl,
+		updates:     make(chan struct{}, 1),
+		status:      "Connecting...",
+	}
+	go o.read()
+	if err = o.join(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *OnlineGame) State() (models.GameState, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.state == nil {
+		return models.GameState{}, false
+	}
+	return *o.state, true
+}
+
+func (o *OnlineGame) PlayerID() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.playerID
+}
+
+func (o *OnlineGame) Status() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.status
+}
+
+func (o *OnlineGame) Play(col int) error {
+	if state, ok := o.State(); !ok || state.Turn != o.PlayerID() {
+		return ErrNotYourTurn
+	}
+	return o.send(models.APIRequest{Type: models.MessageMove, Column: col})
+}
+
+func (o *OnlineGame) Undo() error { return ErrUndoOnline }
+
+func (o *OnlineGame) Resign() error {
+	return o.send(models.APIRequest{Type: models.MessageResign})
+}
+
+// NewGame goes back into the queue for another opponent.
+func (o *OnlineGame) NewGame() error {
+	if state, ok := o.State(); ok && state.InProgress() {
+		return fmt.Errorf("the game is still in progress")
+	}
+	return o.join()
+}
+
+func (o *OnlineGame) Updates() <-chan struct{} { return o.updates }
+
+func (o *OnlineGame) Close() error {
+	o.writeMu.Lock()
+	_ = o.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	o.writeMu.Unlock()
+	return o.conn.Close()
+}
+
+func (o *OnlineGame) join() error {
+	o.mu.Lock()
+	o.state, o.playerID = nil, ""
+	o.status = "Searching for an opponent..."
+	o.mu.Unlock()
+	return o.send(models.APIRequest{Type: models.MessageJoin, Name: o.name, TimeControl: o.timeControl})
+}
+
+func (o *OnlineGame) send(req models.APIRequest) error {
+	o.writeMu.Lock()
+	defer o.writeMu.Unlock()
+	return o.conn.WriteJSON(req)
+}
+
+func (o *OnlineGame) read() {
+	defer notify(o.updates)
+	for {
+		var msg models.APIMessage
+		if err := o.conn.ReadJSON(&msg); err != nil {
+			o.setStatus("Disconnected from the server")
+			return
+		}
+		if err := o.handle(msg); err != nil {
+			o.setStatus(fmt.Sprintf("Failed to read %s message: %s", msg.Type, err.Error()))
+		}
+		notify(o.updates)
+	}
+}
+
+func (o *OnlineGame) handle(msg models.APIMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	switch msg.Type {
+	case models.MessageState:
+		var state models.GameState
+		if err := json.Unmarshal(msg.Data, &state); err != nil {
+			return err
+		}
+		o.state = &state
+	case models.EventQueueUpdate:
+		var status models.QueueStatus
+		if err := json.Unmarshal(msg.Data, &status); err != nil {
+			return err
+		}
+		o.status = fmt.Sprintf("Searching for a %s opponent, rated %d (%s)", status.TimeControl, status.Rating, status.WaitingTime())
+	case models.EventQueueTimeout:
+		o.status = "No opponent found, press n to keep searching"
+	case models.EventMatchFound:
+		var match models.MatchFound
+		if err := json.Unmarshal(msg.Data, &match); err != nil {
+			return err
+		}
+		o.playerID = match.PlayerID
+		o.status = "Playing " + match.Opponent
+	case models.MessageError:
+		var apiErr models.APIError
+		if err := json.Unmarshal(msg.Data, &apiErr); err != nil {
+			return err
+		}
+		o.status = "Server error: " + apiErr.Message
+	}
+	return nil
+}
+
+func (o *OnlineGame) setStatus(status string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.status = status
+}
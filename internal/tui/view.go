@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/models"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiBlue   = "\x1b[34m"
+	ansiGreen  = "\x1b[32m"
+	ansiClear  = "\x1b[H\x1b[2J"
+
+	// in raw mode the terminal doesn't return to the start of the line by itself
+	newline = "\r\n"
+)
+
+var tokenColors = map[string]string{"X": ansiRed, "O": ansiYellow}
+
+// View is everything drawn to the terminal.
+type View struct {
+	State    models.GameState
+	HasState bool
+	PlayerID string
+	Cursor   int
+	Hint     int // -1 when there's no hint to show
+	Status   string
+	Message  string
+	Online   bool
+}
+
+// Render draws the view, the board on the left with the move list beside it.
+func Render(w io.Writer, v View) error {
+	var lines []string
+	lines = append(lines, ansiBold+"CONNECT 4"+ansiReset, "")
+
+	if v.HasState {
+		board := boardLines(v)
+		moves := moveLines(v.State, len(board))
+		for i, line := range board {
+			lines = append(lines, line+"    "+moves[i])
+		}
+		lines = append(lines, "", turnLine(v))
+	}
+	if v.Status != "" {
+		lines = append(lines, v.Status)
+	}
+	if v.Message != "" {
+		lines = append(lines, ansiGreen+v.Message+ansiReset)
+	}
+	lines = append(lines, "", ansiDim+helpLine(v)+ansiReset)
+
+	_, err := io.WriteString(w, ansiClear+strings.Join(lines, newline)+newline)
+	return err
+}
+
+func boardLines(v View) []string {
+	width := len(v.State.Board[0])
+
+	// the selector row above the board
+	var selector strings.Builder
+	selector.WriteString(" ")
+	for col := range width {
+		switch {
+		case col == v.Cursor && v.State.InProgress():
+			selector.WriteString(" " + tokenColors[playerToken(v.State, v.PlayerID)] + "▼" + ansiReset)
+		case col == v.Hint:
+			selector.WriteString(" " + ansiGreen + "?" + ansiReset)
+		default:
+			selector.WriteString("  ")
+		}
+	}
+
+	lines := []string{selector.String()}
+	for _, row := range v.State.Board {
+		var line strings.Builder
+		line.WriteString(ansiBlue + "│" + ansiReset)
+		for _, cell := range row {
+			if color, ok := tokenColors[string(cell)]; ok {
+				line.WriteString(" " + color + "●" + ansiReset)
+			} else {
+				line.WriteString(" " + ansiDim + "·" + ansiReset)
+			}
+		}
+		line.WriteString(ansiBlue + " │" + ansiReset)
+		lines = append(lines, line.String())
+	}
+	lines = append(lines, ansiBlue+"└"+strings.Repeat("─", width*2+1)+"┘"+ansiReset)
+
+	var names strings.Builder
+	names.WriteString(" ")
+	for col := range width {
+		names.WriteString(" " + connectfour.ColumnName(col))
+	}
+	return append(lines, names.String())
+}
+
+// moveLines lists the moves two to a line, padded to the height of the board.
+func moveLines(state models.GameState, height int) []string {
+	lines := make([]string, height)
+	lines[0] = ansiBold + "Moves" + ansiReset
+	moves := []rune(state.Moves)
+	for i := 0; i < len(moves); i += 2 {
+		line := fmt.Sprintf("%2d. %c", i/2+1, moves[i])
+		if i+1 < len(moves) {
+			line += fmt.Sprintf(" %c", moves[i+1])
+		}
+		// keep the latest moves in view if the list is longer than the board
+		row := 1 + i/2
+		if overflow := (len(moves)+1)/2 - (height - 1); overflow > 0 {
+			row -= overflow
+		}
+		if row >= 1 {
+			lines[row] = line
+		}
+	}
+	return lines
+}
+
+func turnLine(v View) string {
+	state := v.State
+	switch {
+	case state.InProgress() && state.Turn == v.PlayerID:
+		return "Your move"
+	case state.InProgress():
+		return "Waiting for " + playerName(state, state.Turn)
+	case state.Drawn():
+		return ansiBold + "Draw" + ansiReset
+	case state.WinnerID == v.PlayerID:
+		return ansiBold + "You win!" + ansiReset
+	case state.WinnerID != "":
+		return ansiBold + playerName(state, state.WinnerID) + " wins" + ansiReset
+	default:
+		return "Game over"
+	}
+}
+
+func helpLine(v View) string {
+	help := "←/→ select · enter drop · 1-7 drop · h hint"
+	if !v.Online {
+		help += " · u undo"
+	}
+	return help + " · r resign · n new game · q quit"
+}
+
+func playerName(state models.GameState, id string) string {
+	for _, player := range state.Players {
+		if player.ID == id {
+			return player.Name
+		}
+	}
+	return "opponent"
+}
+
+func playerToken(state models.GameState, id string) string {
+	for _, player := range state.Players {
+		if player.ID == id {
+			return player.Token
+		}
+	}
+	return ""
+}
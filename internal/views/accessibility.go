@@ -0,0 +1,75 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+// tokenName is the colour a token is called by screen readers. The colour-blind palette keeps
+// the same names, it only shifts the shades and adds patterns.
+func tokenName(token rune) string {
+	if token == 'O' {
+		return "yellow"
+	}
+	return "red"
+}
+
+// columnLabel describes a column for screen readers, e.g. "Column 4, 3 discs, red on top".
+func columnLabel(board connectfour.Board, col int) string {
+	discs, top := 0, rune(0)
+	for row := board.NumRows() - 1; row >= 0; row-- {
+		if cell := board.GetCell(row, col); cell != 0 {
+			discs, top = discs+1, cell
+		}
+	}
+
+	label := fmt.Sprintf("Column %d", col+1)
+	switch {
+	case discs == 0:
+		return label + ", empty"
+	case discs == 1:
+		label += ", 1 disc"
+	default:
+		label += fmt.Sprintf(", %d discs", discs)
+	}
+	label += fmt.Sprintf(", %s on top", tokenName(top))
+	if board.IsColumnFull(col) {
+		label += ", full"
+	}
+	return label
+}
+
+// cellLabel describes a cell for screen readers, rows are counted from the bottom like a
+// disc falls.
+func cellLabel(board connectfour.Board, row, col int, winning bool) string {
+	label := fmt.Sprintf("Column %d, row %d, ", col+1, board.NumRows()-row)
+	cell := board.GetCell(row, col)
+	if cell == 0 {
+		return label + "empty"
+	}
+	label += tokenName(cell)
+	if winning {
+		label += ", winning disc"
+	}
+	return label
+}
+
+// announcement is read out by the board's live region whenever it changes: the last move,
+// then either the result or whose turn it is.
+func announcement(game *connectfour.Game) string {
+	var parts []string
+	if move, ok := game.LastMove(); ok {
+		if player, ok := game.FindPlayer(move.PlayerID); ok {
+			parts = append(parts, fmt.Sprintf("%s dropped a %s disc in column %d.", player.Name(), tokenName(player.Token()), move.Column+1))
+		}
+	}
+	switch {
+	case game.ResultMessage() != "":
+		parts = append(parts, game.ResultMessage()+".")
+	case game.InProgress():
+		parts = append(parts, fmt.Sprintf("%s to move.", game.CurrentPlayer().Name()))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,23 @@
+package views
+
+import (
+	"github.com/Zach51920/connect-four/internal/models"
+	"github.com/a-h/templ"
+)
+
+// Event renders the data of a session event for the browser stream.
+func Event(name string, data any) templ.Component {
+	switch data := data.(type) {
+	case models.QueueStatus:
+		if name == models.EventQueueTimeout {
+			return BotOffer(data.TimeControl)
+		}
+		return QueueStatus(data)
+	case models.MatchFound:
+		return MatchFound(data.Opponent)
+	case models.ServerRestarting:
+		return WarningToast(data.Message)
+	default:
+		return templ.NopComponent
+	}
+}
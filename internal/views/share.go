@@ -0,0 +1,16 @@
+package views
+
+import "context"
+
+type baseURLKey struct{}
+
+// WithBaseURL sets the scheme and host the site is being served from, like
+// "https://example.com", so shared pages can link to themselves in full.
+func WithBaseURL(ctx context.Context, baseURL string) context.Context {
+	return context.WithValue(ctx, baseURLKey{}, baseURL)
+}
+
+func absoluteURL(ctx context.Context, path string) string {
+	baseURL, _ := ctx.Value(baseURLKey{}).(string)
+	return baseURL + path
+}
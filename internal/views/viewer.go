@@ -0,0 +1,26 @@
+package views
+
+import (
+	"context"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+type viewerKey struct{}
+
+// WithViewer sets the player the game is being rendered for, so online players only get
+// controls for their own side. Without a viewer every player can be controlled.
+func WithViewer(ctx context.Context, playerID string) context.Context {
+	return context.WithValue(ctx, viewerKey{}, playerID)
+}
+
+// isOnline reports whether the game is being rendered for one side of an online game.
+func isOnline(ctx context.Context) bool {
+	id, _ := ctx.Value(viewerKey{}).(string)
+	return id != ""
+}
+
+func canAct(ctx context.Context, player connectfour.Player) bool {
+	id, _ := ctx.Value(viewerKey{}).(string)
+	return id == "" || id == player.ID()
+}
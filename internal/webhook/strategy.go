@@ -0,0 +1,200 @@
+// Package webhook plays bots that run as separate HTTP services. The position is posted to the
+// bot's URL as JSON and the bot answers with the column it wants to play.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+	"github.com/Zach51920/connect-four/internal/engine"
+)
+
+const (
+	DefaultTimeout = 2 * time.Second
+	DefaultRetries = 2
+
+	// signed requests carry the time they were sent and an HMAC-SHA256 of "<timestamp>.<body>"
+	HeaderTimestamp = "X-Connect-Four-Timestamp"
+	HeaderSignature = "X-Connect-Four-Signature"
+
+	retryBackoff     = 100 * time.Millisecond
+	maxResponseBytes = 1 << 10
+)
+
+var (
+	ErrBadStatus   = errors.New("unexpected status from bot")
+	ErrIllegalMove = errors.New("bot suggested an illegal move")
+)
+
+type Config struct {
+	Name    string
+	URL     string
+	Secret  string
+	Timeout time.Duration
+	Retries int
+}
+
+// Request is the body posted to the bot. Moves is the game so far in standard notation, it's
+// empty for a position asked about outside of a game. Board is the position drawn from the
+// bot's point of view as in engine.EncodeBoard.
+type Request struct {
+	Moves      string `json:"moves"`
+	Board      string `json:"board"`
+	Rows       int    `json:"rows"`
+	Columns    int    `json:"columns"`
+	Difficulty int    `json:"difficulty"`
+}
+
+// Response is the bot's answer, the column to play in standard notation.
+type Response struct {
+	Column string `json:"column"`
+}
+
+// Strategy asks a remote bot for its moves. When the bot can't be reached or suggests an
+// illegal move, the fallback strategy plays instead.
+type Strategy struct {
+	config   Config
+	client   *http.Client
+	botCfg   *connectfour.Config
+	fallback connectfour.Strategy
+}
+
+func New(cfg Config, botCfg *connectfour.Config, fallback connectfour.Strategy) *Strategy {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	}
+	return &Strategy{
+		config:   cfg,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		botCfg:   botCfg,
+		fallback: fallback,
+	}
+}
+
+// Register makes the bot available to connectfour.NewStrategy under its name, falling back to
+// minimax with the same config.
+func Register(cfg Config) {
+	connectfour.RegisterStrategy(cfg.Name, func(config *connectfour.Config) (connectfour.Strategy, error) {
+		return New(cfg, config, connectfour.NewMinimaxStrat(config)), nil
+	})
+}
+
+func (s *Strategy) Name() string { return s.config.Name }
+
+// WithConfig returns the strategy asking for moves at the config's difficulty.
+func (s *Strategy) WithConfig(config *connectfour.Config) connectfour.Strategy {
+	strategy := *s
+	strategy.botCfg = config
+	if fallback, ok := s.fallback.(connectfour.Configurable); ok {
+		strategy.fallback = fallback.WithConfig(config)
+	}
+	return &strategy
+}
+
+func (s *Strategy) Suggest(board *connectfour.Board, token rune) connectfour.Suggestion {
+	return s.SuggestAfter(nil, board, token)
+}
+
+// SuggestAfter asks the bot for a move, sending it the moves played to reach the position.
+func (s *Strategy) SuggestAfter(moves []connectfour.Move, board *connectfour.Board, token rune) connectfour.Suggestion {
+	col, err := s.request(moves, board, token)
+	if err != nil {
+		slog.Warn("Webhook bot failed to suggest a move, using the fallback", "bot", s.config.Name, "error", err)
+		return s.fallback.Suggest(board, token)
+	}
+	return connectfour.Suggestion{Column: col}
+}
+
+// request posts the position, retrying when the bot can't be reached or has a server error.
+func (s *Strategy) request(moves []connectfour.Move, board *connectfour.Board, token rune) (int, error) {
+	body, err := json.Marshal(Request{
+		Moves:      connectfour.FormatMoves(moves),
+		Board:      engine.EncodeBoard(board, token),
+		Rows:       board.NumRows(),
+		Columns:    board.NumCols(),
+		Difficulty: s.botCfg.Difficulty,
+	})
+	if err != nil {
+		return -1, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	var resp Response
+	for attempt := 0; attempt <= s.config.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(retryBackoff * time.Duration(attempt))
+		}
+		var retry bool
+		if resp, retry, err = s.post(body); err == nil || !retry {
+			break
+		}
+		slog.Debug("Webhook bot request failed", "bot", s.config.Name, "attempt", attempt+1, "error", err)
+	}
+	if err != nil {
+		return -1, err
+	}
+
+	col, err := connectfour.ParseColumn(resp.Column)
+	if err != nil || col >= board.NumCols() || board.IsColumnFull(col) {
+		return -1, fmt.Errorf("%w: %q", ErrIllegalMove, resp.Column)
+	}
+	return col, nil
+}
+
+// post sends a single request, retry reports whether a failure is worth trying again.
+func (s *Strategy) post(body []byte) (resp Response, retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return resp, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, Sign(s.config.Secret, timestamp, body))
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return resp, true, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		retry = res.StatusCode >= http.StatusInternalServerError || res.StatusCode == http.StatusTooManyRequests
+		return resp, retry, fmt.Errorf("%w: %s", ErrBadStatus, res.Status)
+	}
+	if err = json.NewDecoder(io.LimitReader(res.Body, maxResponseBytes)).Decode(&resp); err != nil {
+		return resp, false, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp, false, nil
+}
+
+// Sign returns the signature header for a request body sent at timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a request's signature, for bots written in Go.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Zach51920/connect-four/internal/connectfour"
+)
+
+// fixedStrategy always suggests the same column.
+type fixedStrategy int
+
+func (f fixedStrategy) Name() string { return "FIXED" }
+func (f fixedStrategy) Suggest(*connectfour.Board, rune) connectfour.Suggestion {
+	return connectfour.Suggestion{Column: int(f)}
+}
+
+func TestStrategy_Suggest(t *testing.T) {
+	const secret = "shh"
+	const fallbackCol = 6
+
+	tests := []struct {
+		name     string
+		failures int // requests answered with a 503 before the bot recovers
+		delay    time.Duration
+		column   string
+		status   int
+		want     int
+	}{
+		{name: "plays the bot's move", column: "c", status: http.StatusOK, want: 2},
+		{name: "retries server errors", failures: 2, column: "c", status: http.StatusOK, want: 2},
+		{name: "gives up after retries", failures: 3, column: "c", status: http.StatusOK, want: fallbackCol},
+		{name: "illegal move", column: "d", status: http.StatusOK, want: fallbackCol},
+		{name: "off the board", column: "z", status: http.StatusOK, want: fallbackCol},
+		{name: "client error", column: "c", status: http.StatusBadRequest, want: fallbackCol},
+		{name: "timeout", delay: 200 * time.Millisecond, column: "c", status: http.StatusOK, want: fallbackCol},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			bot := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if !Verify(secret, r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)) {
+					t.Errorf("request signature did not verify")
+				}
+				var req Request
+				if err := json.Unmarshal(body, &req); err != nil || req.Moves != "dddddd" || req.Columns != 7 {
+					t.Errorf("unexpected request %s: %v", body, err)
+				}
+
+				if int(requests.Add(1)) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				time.Sleep(tt.delay)
+				w.WriteHeader(tt.status)
+				_ = json.NewEncoder(w).Encode(Response{Column: tt.column})
+			}))
+			defer bot.Close()
+
+			cfg := Config{Name: "remote", URL: bot.URL, Secret: secret, Timeout: 100 * time.Millisecond, Retries: 2}
+			strategy := New(cfg, connectfour.DefaultConfig(), fixedStrategy(fallbackCol))
+
+			// fill column d so it's an illegal move
+			game := connectfour.NewGame(connectfour.NewHumanPlayerPair())
+			for range connectfour.DefaultBoardRows {
+				if _, err := game.Play(game.CurrentPlayer(), 3); err != nil {
+					t.Fatalf("failed to fill column d: %v", err)
+				}
+				game.NextPlayer()
+			}
+			if got := strategy.SuggestAfter(game.Moves, game.Board, 'X').Column; got != tt.want {
+				t.Errorf("expected column %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register(Config{Name: "REMOTE", URL: "http://localhost:0"})
+	strategy, err := connectfour.NewStrategy("REMOTE", connectfour.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create registered strategy: %v", err)
+	}
+	if strategy.Name() != "REMOTE" {
+		t.Errorf("expected strategy REMOTE, got %s", strategy.Name())
+	}
+}
@@ -21,8 +21,12 @@ func main() {
 
 	// create and run the server
 	s := server.New(cfg.Server)
-	defer s.Close()
-	if err := s.Run(); err != nil {
-		log.Fatalf("Failed to start server: %s", err.Error())
+	err := s.Run()
+	// close before exiting, a failed shutdown still has to close the repository and flush traces
+	if closeErr := s.Close(); closeErr != nil {
+		slog.Error("Failed to close server", "error", closeErr)
+	}
+	if err != nil {
+		log.Fatalf("Server stopped: %s", err.Error())
 	}
 }
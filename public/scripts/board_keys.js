@@ -0,0 +1,47 @@
+// Plays the board from the keyboard: the number keys drop a disc in their column, the arrow
+// keys move between the drop buttons and enter drops a disc in the focused one.
+(function () {
+    function dropButtons() {
+        return Array.from(document.querySelectorAll('#dropzone-container button[data-column]'));
+    }
+
+    function playable(button) {
+        return button && button.getAttribute('aria-disabled') !== 'true';
+    }
+
+    function focusColumn(buttons, index) {
+        const button = buttons[Math.max(0, Math.min(buttons.length - 1, index))];
+        if (button) {
+            button.focus();
+        }
+    }
+
+    document.addEventListener('keydown', function (event) {
+        if (event.ctrlKey || event.metaKey || event.altKey || event.target.closest('input, select, textarea, .modal')) {
+            return;
+        }
+        const buttons = dropButtons();
+        if (buttons.length === 0) {
+            return;
+        }
+
+        const current = buttons.indexOf(document.activeElement);
+        if (/^[1-9]$/.test(event.key)) {
+            const button = buttons[parseInt(event.key, 10) - 1];
+            if (playable(button)) {
+                button.click();
+            }
+        } else if (event.key === 'ArrowLeft') {
+            focusColumn(buttons, current === -1 ? Math.floor(buttons.length / 2) : current - 1);
+        } else if (event.key === 'ArrowRight') {
+            focusColumn(buttons, current === -1 ? Math.floor(buttons.length / 2) : current + 1);
+        } else if (event.key === 'Enter' && current !== -1) {
+            if (playable(buttons[current])) {
+                buttons[current].click();
+            }
+        } else {
+            return;
+        }
+        event.preventDefault();
+    });
+})();
@@ -1,3 +1,15 @@
+// announce reads out the board status through the live region, but only once it changes since
+// the board is also re-rendered without a move being made.
+function announce() {
+    const status = document.getElementById('board-status');
+    const announcer = document.getElementById('board-announcer');
+    if (!status || !announcer || announcer.dataset.status === status.textContent) {
+        return;
+    }
+    announcer.dataset.status = status.textContent;
+    announcer.textContent = status.textContent;
+}
+
 function setupSSE() {
     if (typeof EventSource !== "undefined") {
         const source = new EventSource('/game/stream');
@@ -12,14 +24,44 @@ function setupSSE() {
 
         source.addEventListener('board-update', function (event) {
             console.log('Board update received');
+            // keep keyboard players on the column they were on once the buttons are swapped out
+            const focused = document.activeElement && document.activeElement.dataset.column;
             document.getElementById('board-container').innerHTML = event.data;
             htmx.process(document.getElementById('board-container'));
+            if (focused !== undefined) {
+                const button = document.querySelector(`#dropzone-container button[data-column="${focused}"]`);
+                if (button) {
+                    button.focus();
+                }
+            }
+            announce();
         });
 
         source.addEventListener('score-update', function (event) {
             console.log('Score update received');
             document.getElementById('score-container').innerHTML = event.data;
         });
+
+        source.addEventListener('history-update', function (event) {
+            console.log('Move history update received');
+            document.getElementById('history-container').innerHTML = event.data;
+            htmx.process(document.getElementById('history-container'));
+        });
+
+        source.addEventListener('eval-update', function (event) {
+            console.log('Evaluation update received');
+            const container = document.getElementById('eval-container');
+            if (container) {
+                container.innerHTML = event.data;
+                htmx.process(container);
+            }
+        });
+
+        // the stream reconnects by itself once the server is back, the game is restored then
+        source.addEventListener('server-restarting', function (event) {
+            console.log('Server restarting');
+            document.body.insertAdjacentHTML('beforeend', event.data);
+        });
     } else {
         console.error('SSE not supported');
     }
@@ -0,0 +1,23 @@
+(function () {
+    function copyMoves(button) {
+        navigator.clipboard.writeText(button.dataset.moves).then(function () {
+            button.textContent = 'Copied';
+            setTimeout(function () {
+                button.textContent = 'Copy';
+            }, 1500);
+        }, function (err) {
+            console.error('Failed to copy moves', err);
+        });
+    }
+
+    document.addEventListener('click', function (event) {
+        const copy = event.target.closest('#history-copy');
+        if (copy) {
+            copyMoves(copy);
+            return;
+        }
+        if (event.target.closest('#history-preview-close')) {
+            document.getElementById('history-preview').innerHTML = '';
+        }
+    });
+})();
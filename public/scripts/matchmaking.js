@@ -0,0 +1,58 @@
+// streams queue updates while the player is searching, the searching card starts the stream
+// and any event that leaves the queue closes it again
+function setupMatchmaking() {
+    if (!document.getElementById('matchmaking-search') || window.matchmakingSource) {
+        return;
+    }
+    if (typeof EventSource === "undefined") {
+        console.error('SSE not supported');
+        return;
+    }
+
+    const source = new EventSource('/matchmaking/stream');
+    window.matchmakingSource = source;
+    const close = function () {
+        source.close();
+        window.matchmakingSource = null;
+    };
+    const swap = function (html) {
+        const container = document.getElementById('matchmaking-container');
+        if (container) {
+            container.innerHTML = html;
+            htmx.process(container);
+        }
+    };
+
+    source.onerror = function (event) {
+        console.error('SSE connection error', event);
+    };
+
+    source.addEventListener('queue-update', function (event) {
+        const status = document.getElementById('queue-status');
+        if (status) {
+            status.innerHTML = event.data;
+        } else {
+            close();
+        }
+    });
+
+    source.addEventListener('queue-timeout', function (event) {
+        close();
+        swap(event.data);
+    });
+
+    source.addEventListener('match-found', function (event) {
+        close();
+        swap(event.data);
+        htmx.ajax('GET', '/game', {target: '#root'});
+    });
+
+    // the queue doesn't survive a restart, so there's nothing to reconnect to
+    source.addEventListener('server-restarting', function (event) {
+        close();
+        document.body.insertAdjacentHTML('beforeend', event.data);
+    });
+}
+
+document.addEventListener('htmx:load', setupMatchmaking);
+setupMatchmaking();
@@ -0,0 +1,32 @@
+// The colour-blind palette is a per-browser preference, it's applied before the page renders
+// so discs don't flash in the regular colours.
+(function () {
+    const storageKey = 'colorblind-palette';
+
+    function enabled() {
+        return localStorage.getItem(storageKey) === 'on';
+    }
+
+    function apply(on) {
+        document.documentElement.classList.toggle('colorblind', on);
+        const toggle = document.getElementById('palette-toggle');
+        if (toggle) {
+            toggle.checked = on;
+        }
+    }
+
+    apply(enabled());
+
+    document.addEventListener('change', function (event) {
+        if (event.target.id !== 'palette-toggle') {
+            return;
+        }
+        localStorage.setItem(storageKey, event.target.checked ? 'on' : 'off');
+        apply(event.target.checked);
+    });
+
+    // the settings are swapped in by htmx, so the toggle needs syncing when it appears
+    document.addEventListener('htmx:load', function () {
+        apply(enabled());
+    });
+})();
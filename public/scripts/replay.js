@@ -0,0 +1,84 @@
+(function () {
+    let autoplayTimer = null;
+
+    function click(id) {
+        const button = document.getElementById(id);
+        if (!button || button.classList.contains('btn-disabled')) {
+            return false;
+        }
+        button.click();
+        return true;
+    }
+
+    function speed() {
+        const select = document.getElementById('replay-speed');
+        return select ? parseInt(select.value, 10) : 800;
+    }
+
+    function stopAutoplay() {
+        clearTimeout(autoplayTimer);
+        autoplayTimer = null;
+        const button = document.getElementById('replay-autoplay');
+        if (button) {
+            button.textContent = 'Play';
+        }
+    }
+
+    function stepAutoplay() {
+        if (!click('replay-next')) {
+            stopAutoplay();
+            return;
+        }
+        autoplayTimer = setTimeout(stepAutoplay, speed());
+    }
+
+    function toggleAutoplay() {
+        if (autoplayTimer !== null) {
+            stopAutoplay();
+            return;
+        }
+        // restart from the beginning if we're already at the end
+        const board = document.getElementById('replay-board');
+        if (board && board.dataset.ply === board.dataset.plies) {
+            click('replay-first');
+        }
+        document.getElementById('replay-autoplay').textContent = 'Pause';
+        autoplayTimer = setTimeout(stepAutoplay, speed());
+    }
+
+    document.addEventListener('click', function (event) {
+        if (event.target.id === 'replay-autoplay') {
+            toggleAutoplay();
+        }
+    });
+
+    document.addEventListener('keydown', function (event) {
+        if (!document.getElementById('replay-board') || event.target.tagName === 'SELECT') {
+            return;
+        }
+        switch (event.key) {
+            case 'ArrowLeft':
+                stopAutoplay();
+                click('replay-prev');
+                break;
+            case 'ArrowRight':
+                stopAutoplay();
+                click('replay-next');
+                break;
+            case 'Home':
+                stopAutoplay();
+                click('replay-first');
+                break;
+            case 'End':
+                stopAutoplay();
+                click('replay-last');
+                break;
+            case ' ':
+                toggleAutoplay();
+                break;
+            default:
+                return;
+        }
+        event.preventDefault();
+    });
+})();